@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloverstd/tcping/ping"
+)
+
+// The exit status of tcping, the most severe status of all targets is used.
+const (
+	// exitSuccess is all probes are successful
+	exitSuccess = 0
+	// exitPartialLoss is some probes failed
+	exitPartialLoss = 1
+	// exitFailure is all probes of a target failed or the command failed
+	exitFailure = 2
+	// exitThreshold is --max-loss or --max-rtt is breached or a certificate expires soon
+	exitThreshold = 3
+	// exitUsage is invalid arguments or flags
+	exitUsage = 4
+)
+
+// severity orders the exit status, the higher is more severe.
+var severity = map[int]int{
+	exitSuccess:     0,
+	exitPartialLoss: 1,
+	exitThreshold:   2,
+	exitFailure:     3,
+	exitUsage:       4,
+}
+
+// threshold is the limits evaluated against the results.
+type threshold struct {
+	// maxLoss is the max loss percentage, negative is disabled
+	maxLoss float64
+	// maxRTT is the max average trip time, empty is disabled
+	maxRTT string
+
+	maxRTTDuration time.Duration
+}
+
+func (t *threshold) parse() (err error) {
+	// -1 is the default of --max-loss
+	if t.maxLoss != -1 && (t.maxLoss < 0 || t.maxLoss > 100) {
+		return fmt.Errorf("max loss %v should be between 0 and 100", t.maxLoss)
+	}
+	if t.maxRTT != "" {
+		t.maxRTTDuration, err = ping.ParseDuration(t.maxRTT)
+	}
+	return
+}
+
+// exitStatus returns the most severe exit status of results.
+func exitStatus(results []ping.Result, t threshold) int {
+	status := exitSuccess
+	for _, result := range results {
+		if s := t.status(result); severity[s] > severity[status] {
+			status = s
+		}
+	}
+	return status
+}
+
+func (t threshold) status(result ping.Result) int {
+	if result.Counter == 0 {
+		// interrupted before any probe, nothing is known to be healthy
+		return exitFailure
+	}
+	if result.SuccessCounter == 0 {
+		return exitFailure
+	}
+	if t.maxRTTDuration > 0 && result.Avg() > t.maxRTTDuration {
+		return exitThreshold
+	}
+	if result.WarningCounter > 0 {
+		return exitThreshold
+	}
+	if result.Failed() == 0 {
+		return exitSuccess
+	}
+	if t.maxLoss < 0 {
+		return exitPartialLoss
+	}
+	if loss := float64(result.Failed()) / float64(result.Counter) * 100; loss > t.maxLoss {
+		return exitThreshold
+	}
+	return exitSuccess
+}
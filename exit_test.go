@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cloverstd/tcping/ping"
+)
+
+func TestExitStatus(t *testing.T) {
+	result := func(counter, success, warnings int, avg time.Duration) ping.Result {
+		return ping.Result{
+			Counter:        counter,
+			SuccessCounter: success,
+			WarningCounter: warnings,
+			TotalDuration:  avg * time.Duration(success),
+		}
+	}
+	disabled := threshold{maxLoss: -1}
+
+	for _, tt := range []struct {
+		name      string
+		results   []ping.Result
+		threshold threshold
+		status    int
+	}{
+		{"success", []ping.Result{result(4, 4, 0, time.Millisecond)}, disabled, exitSuccess},
+		{"partial loss", []ping.Result{result(4, 3, 0, time.Millisecond)}, disabled, exitPartialLoss},
+		{"total failure", []ping.Result{result(4, 0, 0, 0)}, disabled, exitFailure},
+		{"loss within max loss", []ping.Result{result(4, 3, 0, time.Millisecond)}, threshold{maxLoss: 25}, exitSuccess},
+		{"loss over max loss", []ping.Result{result(4, 2, 0, time.Millisecond)}, threshold{maxLoss: 25}, exitThreshold},
+		{"avg over max rtt", []ping.Result{result(4, 4, 0, time.Second)}, threshold{maxLoss: -1, maxRTTDuration: time.Millisecond}, exitThreshold},
+		{"certificate warning", []ping.Result{result(4, 4, 1, time.Millisecond)}, disabled, exitThreshold},
+		{"no probe", []ping.Result{result(0, 0, 0, 0)}, disabled, exitFailure},
+		{"most severe target", []ping.Result{result(4, 3, 0, time.Millisecond), result(4, 0, 0, 0)}, disabled, exitFailure},
+	} {
+		if status := exitStatus(tt.results, tt.threshold); status != tt.status {
+			t.Errorf("%s: exit status should be %d, got %d", tt.name, tt.status, status)
+		}
+	}
+}
+
+func TestThresholdParse(t *testing.T) {
+	for _, tt := range []struct {
+		threshold threshold
+		valid     bool
+	}{
+		{threshold{maxLoss: -1}, true},
+		{threshold{maxLoss: 0}, true},
+		{threshold{maxLoss: 100}, true},
+		{threshold{maxLoss: 25, maxRTT: "100ms"}, true},
+		{threshold{maxLoss: -5}, false},
+		{threshold{maxLoss: 101}, false},
+		{threshold{maxLoss: -1, maxRTT: "fast"}, false},
+	} {
+		if err := tt.threshold.parse(); (err == nil) != tt.valid {
+			t.Errorf("%+v: the threshold should be valid %v, got %v", tt.threshold, tt.valid, err)
+		}
+	}
+}
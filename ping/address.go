@@ -0,0 +1,328 @@
+package ping
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolve looks up the IP addresses of host with resolver, restricted to the IP version 4 or 6,
+// the host is returned as is if it is an IP of the IP version.
+func Resolve(ctx context.Context, resolver *net.Resolver, host string, ipVersion int) ([]string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		isV4 := ip.To4() != nil
+		if (ipVersion == 4 && !isV4) || (ipVersion == 6 && isV4) {
+			return nil, fmt.Errorf("%s is not an IPv%d address", host, ipVersion)
+		}
+		return []string{host}, nil
+	}
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	ipAddrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, 0, len(ipAddrs))
+	for _, ipAddr := range ipAddrs {
+		isV4 := ipAddr.IP.To4() != nil
+		if (ipVersion == 4 && !isV4) || (ipVersion == 6 && isV4) {
+			continue
+		}
+		addrs = append(addrs, ipAddr.IP.String())
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no IPv%d address found for %s", ipVersion, host)
+	}
+	return addrs, nil
+}
+
+// AddressFactory creates the Ping which connects to address.
+type AddressFactory func(address string) (Ping, error)
+
+var _ Ping = (*EachAddress)(nil)
+
+// EachAddress resolves the host every round and pings all addresses concurrently,
+// the result of every address is reported in the meta of the Stats.
+type EachAddress struct {
+	host    string
+	option  *Option
+	factory AddressFactory
+
+	mu    sync.Mutex
+	pings map[string]Ping
+}
+
+// NewEachAddress create an EachAddress, the host is resolved with the resolver and the IP version of op.
+func NewEachAddress(host string, op *Option, factory AddressFactory) *EachAddress {
+	return &EachAddress{
+		host:    host,
+		option:  op,
+		factory: factory,
+		pings:   map[string]Ping{},
+	}
+}
+
+func (e *EachAddress) Ping(ctx context.Context) *Stats {
+	stats := Stats{
+		Meta: map[string]fmt.Stringer{},
+	}
+	start := time.Now()
+	addrs, err := Resolve(ctx, e.option.Resolver, e.host, e.option.IPVersion)
+	stats.DNSDuration = time.Since(start)
+	if err != nil {
+		stats.Duration = stats.DNSDuration
+		stats.Error = err
+		return &stats
+	}
+	sort.Strings(addrs)
+	e.prune(addrs)
+
+	results := make([]*Stats, len(addrs))
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		p, err := e.load(addr)
+		if err != nil {
+			results[i] = &Stats{Error: err}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, p Ping) {
+			defer wg.Done()
+			results[i] = p.Ping(ctx)
+		}(i, p)
+	}
+	wg.Wait()
+	stats.Duration = time.Since(start)
+
+	var failed []string
+	for i, result := range results {
+		if result.Error != nil {
+			stats.Meta[addrs[i]] = String(FormatError(result.Error))
+			failed = append(failed, addrs[i])
+		} else {
+			stats.Meta[addrs[i]] = result.Duration
+		}
+	}
+	stats.Address = strings.Join(addrs, ",")
+	if len(failed) > 0 {
+		stats.Error = fmt.Errorf("%d of %d addresses failed: %s", len(failed), len(addrs), strings.Join(failed, ","))
+		return &stats
+	}
+	stats.Connected = true
+	return &stats
+}
+
+func (e *EachAddress) load(addr string) (Ping, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if p, ok := e.pings[addr]; ok {
+		return p, nil
+	}
+	p, err := e.factory(addr)
+	if err != nil {
+		return nil, err
+	}
+	e.pings[addr] = p
+	return p, nil
+}
+
+// prune forgets the Pings of the addresses which are not resolved anymore.
+func (e *EachAddress) prune(addrs []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	current := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		current[addr] = true
+	}
+	for addr := range e.pings {
+		if !current[addr] {
+			delete(e.pings, addr)
+		}
+	}
+}
+
+// DefaultResolveInterval is the interval to resolve the host of AddressGroup again.
+const DefaultResolveInterval = time.Minute
+
+// PingerFactory creates the Pinger which connects to address.
+type PingerFactory func(address string) (*Pinger, error)
+
+var _ Runner = (*AddressGroup)(nil)
+
+// AddressGroup runs a Pinger for every resolved address of the host,
+// the host is resolved again periodically, the Pingers of new addresses are started
+// and the Pingers of the addresses which are gone are stopped.
+type AddressGroup struct {
+	host            string
+	option          *Option
+	factory         PingerFactory
+	resolveInterval time.Duration
+
+	mu sync.Mutex
+	// running is the number of pingers whose Ping has not returned
+	running int
+	// finishedC is closed when the running pingers are done, no pinger is started after it
+	finishedC chan struct{}
+	// pingers is the running pinger of every address
+	pingers map[string]*Pinger
+	// all is every pinger in the order of starting, including the stopped ones
+	all []*Pinger
+	err error
+
+	stopOnce sync.Once
+	stopC    chan struct{}
+}
+
+// NewAddressGroup create an AddressGroup, the host is resolved with the resolver and the IP version of op
+// every resolveInterval, DefaultResolveInterval is used if it is not positive.
+func NewAddressGroup(host string, op *Option, resolveInterval time.Duration, factory PingerFactory) *AddressGroup {
+	if resolveInterval <= 0 {
+		resolveInterval = DefaultResolveInterval
+	}
+	return &AddressGroup{
+		host:            host,
+		option:          op,
+		factory:         factory,
+		resolveInterval: resolveInterval,
+		pingers:         map[string]*Pinger{},
+		finishedC:       make(chan struct{}),
+		stopC:           make(chan struct{}),
+	}
+}
+
+// Ping resolves the host and pings all addresses until all pingers are done or it is stopped,
+// it returns after the Ping of every pinger returned.
+func (g *AddressGroup) Ping() {
+	defer g.Stop()
+
+	if err := g.resolve(); err != nil {
+		g.mu.Lock()
+		g.err = err
+		g.mu.Unlock()
+	}
+	g.mu.Lock()
+	if g.running == 0 {
+		g.finish()
+	}
+	g.mu.Unlock()
+
+	ticker := time.NewTicker(g.resolveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// keep the current addresses if the lookup failed
+			_ = g.resolve()
+		case <-g.finishedC:
+			return
+		case <-g.Done():
+			// the stopped pingers return after their probes in flight
+			<-g.finishedC
+			return
+		}
+	}
+}
+
+// finish closes finishedC once, g.mu is held.
+func (g *AddressGroup) finish() {
+	select {
+	case <-g.finishedC:
+	default:
+		close(g.finishedC)
+	}
+}
+
+func (g *AddressGroup) resolve() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	addrs, err := Resolve(ctx, g.option.Resolver, g.host, g.option.IPVersion)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	select {
+	case <-g.stopC:
+		return nil
+	case <-g.finishedC:
+		// all pingers are done, e.g. -c is reached
+		return nil
+	default:
+	}
+
+	current := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		current[addr] = true
+		if _, ok := g.pingers[addr]; ok {
+			continue
+		}
+		p, err := g.factory(addr)
+		if err != nil {
+			g.err = err
+			continue
+		}
+		p.SetIP(addr)
+		g.pingers[addr] = p
+		g.all = append(g.all, p)
+		g.running++
+		go func() {
+			p.Ping()
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			if g.running--; g.running == 0 {
+				g.finish()
+			}
+		}()
+	}
+	// the results of the addresses which are gone are kept for the summary
+	for addr, p := range g.pingers {
+		if !current[addr] {
+			p.Stop()
+			delete(g.pingers, addr)
+		}
+	}
+	return nil
+}
+
+// Stop stops all pingers.
+func (g *AddressGroup) Stop() {
+	g.stopOnce.Do(func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		for _, p := range g.pingers {
+			p.Stop()
+		}
+		if g.running == 0 {
+			g.finish()
+		}
+		close(g.stopC)
+	})
+}
+
+// Done is closed when all pingers are done or the group is stopped.
+func (g *AddressGroup) Done() <-chan struct{} {
+	return g.stopC
+}
+
+// Pingers returns the pinger of every address in the order of starting,
+// the pingers of the addresses which are gone are included.
+func (g *AddressGroup) Pingers() []*Pinger {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	pingers := make([]*Pinger, len(g.all))
+	copy(pingers, g.all)
+	return pingers
+}
+
+// Err returns the last error of resolving the host or creating a pinger.
+func (g *AddressGroup) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.err
+}
@@ -0,0 +1,155 @@
+package ping
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type pingFunc func(ctx context.Context) *Stats
+
+func (f pingFunc) Ping(ctx context.Context) *Stats {
+	return f(ctx)
+}
+
+func TestOption(t *testing.T) {
+
+	Convey("Option", t, func() {
+		Convey("network", func() {
+			So((&Option{}).Network("tcp"), ShouldEqual, "tcp")
+			So((&Option{IPVersion: 4}).Network("tcp"), ShouldEqual, "tcp4")
+			So((&Option{IPVersion: 6}).Network("udp"), ShouldEqual, "udp6")
+		})
+
+		Convey("dial address", func() {
+			So((&Option{}).DialAddress("example.com:443"), ShouldEqual, "example.com:443")
+			So((&Option{Address: "::1"}).DialAddress("example.com:443"), ShouldEqual, "[::1]:443")
+		})
+
+		Convey("dialer", func() {
+			So((&Option{}).Dialer("tcp").LocalAddr, ShouldBeNil)
+			op := &Option{SourceIP: net.ParseIP("127.0.0.1"), Interface: "lo"}
+			So(op.Dialer("tcp").LocalAddr, ShouldResemble, &net.TCPAddr{IP: op.SourceIP})
+			So(op.Dialer("udp4").LocalAddr, ShouldResemble, &net.UDPAddr{IP: op.SourceIP})
+			So(op.Dialer("tcp").Control, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestResolve(t *testing.T) {
+
+	Convey("Resolve an IP", t, func() {
+		addrs, err := Resolve(context.Background(), nil, "127.0.0.1", 4)
+		So(err, ShouldBeNil)
+		So(addrs, ShouldResemble, []string{"127.0.0.1"})
+
+		addrs, err = Resolve(context.Background(), nil, "::1", 0)
+		So(err, ShouldBeNil)
+		So(addrs, ShouldResemble, []string{"::1"})
+
+		_, err = Resolve(context.Background(), nil, "1.2.3.4", 6)
+		So(err, ShouldNotBeNil)
+		_, err = Resolve(context.Background(), nil, "::1", 4)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestEachAddress(t *testing.T) {
+
+	Convey("EachAddress", t, func() {
+		Convey("success", func() {
+			p := NewEachAddress("127.0.0.1", &Option{}, func(address string) (Ping, error) {
+				return pingFunc(func(ctx context.Context) *Stats {
+					return &Stats{Connected: true, Duration: time.Millisecond}
+				}), nil
+			})
+			stats := p.Ping(context.Background())
+			So(stats.Connected, ShouldBeTrue)
+			So(stats.Meta["127.0.0.1"], ShouldEqual, time.Millisecond)
+		})
+
+		Convey("failed address", func() {
+			p := NewEachAddress("::1", &Option{}, func(address string) (Ping, error) {
+				return pingFunc(func(ctx context.Context) *Stats {
+					return &Stats{Error: errors.New("refused")}
+				}), nil
+			})
+			stats := p.Ping(context.Background())
+			So(stats.Connected, ShouldBeFalse)
+			So(stats.Error.Error(), ShouldEqual, "1 of 1 addresses failed: ::1")
+			So(stats.Meta["::1"].String(), ShouldEqual, "refused")
+		})
+	})
+}
+
+func TestAddressGroup(t *testing.T) {
+
+	Convey("AddressGroup", t, func() {
+		u, _ := url.Parse("tcp://127.0.0.1:80")
+		newGroup := func(host string) *AddressGroup {
+			return NewAddressGroup(host, &Option{}, time.Hour, func(address string) (*Pinger, error) {
+				p := pingFunc(func(ctx context.Context) *Stats {
+					return &Stats{Connected: true, Duration: time.Millisecond, Address: address}
+				})
+				return NewPinger(NewTextReporter(io.Discard, u), u, p, time.Millisecond, 2), nil
+			})
+		}
+
+		Convey("ping every address", func() {
+			g := newGroup("127.0.0.1")
+			g.Ping()
+			So(g.Err(), ShouldBeNil)
+			pingers := g.Pingers()
+			So(pingers, ShouldHaveLength, 1)
+			result := pingers[0].Result()
+			So(result.Counter, ShouldEqual, 2)
+			So(result.Target.IP, ShouldEqual, "127.0.0.1")
+		})
+
+		Convey("no pinger is started after all pingers are done", func() {
+			g := newGroup("127.0.0.1")
+			g.Ping()
+			g.mu.Lock()
+			// the address looks new to the next resolve
+			delete(g.pingers, "127.0.0.1")
+			g.mu.Unlock()
+			So(g.resolve(), ShouldBeNil)
+			So(g.Pingers(), ShouldHaveLength, 1)
+		})
+
+		Convey("stop waits for the probes in flight", func() {
+			started := make(chan struct{}, 1)
+			g := NewAddressGroup("127.0.0.1", &Option{}, time.Hour, func(address string) (*Pinger, error) {
+				p := pingFunc(func(ctx context.Context) *Stats {
+					started <- struct{}{}
+					<-ctx.Done()
+					time.Sleep(10 * time.Millisecond)
+					return &Stats{Connected: true, Duration: time.Millisecond}
+				})
+				return NewPinger(NewTextReporter(io.Discard, u), u, p, time.Millisecond, 0), nil
+			})
+			returned := make(chan struct{})
+			go func() {
+				g.Ping()
+				close(returned)
+			}()
+			<-started
+			g.Stop()
+			<-returned
+			So(g.Pingers()[0].Result().Counter, ShouldEqual, 1)
+		})
+
+		Convey("resolve failed", func() {
+			g := newGroup("invalid.invalid")
+			g.Ping()
+			So(g.Err(), ShouldNotBeNil)
+			So(g.Pingers(), ShouldBeEmpty)
+		})
+	})
+}
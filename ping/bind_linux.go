@@ -0,0 +1,20 @@
+package ping
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// bindToDevice binds the socket to the interface with SO_BINDTODEVICE.
+func bindToDevice(c syscall.RawConn, iface string) error {
+	var err error
+	if cerr := c.Control(func(fd uintptr) {
+		err = syscall.SetsockoptString(int(fd), syscall.SOL_SOCKET, syscall.SO_BINDTODEVICE, iface)
+	}); cerr != nil {
+		return cerr
+	}
+	if err != nil {
+		return fmt.Errorf("bind to interface %s failed, %w", iface, err)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package ping
+
+import (
+	"errors"
+	"syscall"
+)
+
+// bindToDevice is only supported on linux.
+func bindToDevice(c syscall.RawConn, iface string) error {
+	return errors.New("binding to an interface is only supported on linux")
+}
@@ -0,0 +1,41 @@
+package ping
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrCertificateExpired is reported when a certificate of the chain is expired.
+var ErrCertificateExpired = errors.New("certificate expired")
+
+// CheckCertificates checks the expiry of the certificate chain of the peer when warnDays is positive,
+// the days remaining of the certificate which expires first are reported as certDays meta.
+// The probe is failed when it is expired and has a warning when it expires within warnDays.
+func CheckCertificates(stats *Stats, chain []*x509.Certificate, warnDays int) {
+	if warnDays <= 0 || len(chain) == 0 {
+		return
+	}
+	first := chain[0]
+	for _, cert := range chain[1:] {
+		if cert.NotAfter.Before(first.NotAfter) {
+			first = cert
+		}
+	}
+
+	remaining := time.Until(first.NotAfter)
+	days := int(math.Floor(remaining.Hours() / 24))
+	if stats.Meta == nil {
+		stats.Meta = map[string]fmt.Stringer{}
+	}
+	stats.Meta["certDays"] = Int(days)
+
+	if remaining <= 0 {
+		stats.Connected = false
+		stats.Error = fmt.Errorf("%w, %s expired at %s", ErrCertificateExpired, first.Subject.CommonName, first.NotAfter.Format(time.RFC3339))
+	} else if days < warnDays {
+		stats.Warning = fmt.Sprintf("certificate %s expires in %d days", first.Subject.CommonName, days)
+	}
+}
@@ -0,0 +1,53 @@
+package ping
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckCertificates(t *testing.T) {
+
+	cert := func(name string, notAfter time.Duration) *x509.Certificate {
+		return &x509.Certificate{
+			Subject:  pkix.Name{CommonName: name},
+			NotAfter: time.Now().Add(notAfter),
+		}
+	}
+	day := 24 * time.Hour
+
+	Convey("CheckCertificates", t, func() {
+		Convey("disabled", func() {
+			stats := Stats{Connected: true}
+			CheckCertificates(&stats, []*x509.Certificate{cert("leaf", -day)}, 0)
+			So(stats.Connected, ShouldBeTrue)
+			So(stats.Meta, ShouldBeNil)
+		})
+
+		Convey("valid", func() {
+			stats := Stats{Connected: true}
+			CheckCertificates(&stats, []*x509.Certificate{cert("leaf", 90*day+time.Hour)}, 30)
+			So(stats.Connected, ShouldBeTrue)
+			So(stats.Warning, ShouldBeEmpty)
+			So(stats.Meta["certDays"], ShouldEqual, Int(90))
+		})
+
+		Convey("chain expires soon", func() {
+			stats := Stats{Connected: true}
+			CheckCertificates(&stats, []*x509.Certificate{cert("leaf", 90*day), cert("intermediate", 10*day+time.Hour)}, 30)
+			So(stats.Connected, ShouldBeTrue)
+			So(stats.Warning, ShouldContainSubstring, "intermediate expires in 10 days")
+		})
+
+		Convey("expired", func() {
+			stats := Stats{Connected: true}
+			CheckCertificates(&stats, []*x509.Certificate{cert("leaf", -time.Hour)}, 30)
+			So(stats.Connected, ShouldBeFalse)
+			So(errors.Is(stats.Error, ErrCertificateExpired), ShouldBeTrue)
+		})
+	})
+}
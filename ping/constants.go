@@ -6,4 +6,6 @@ const (
 	DefaultCounter  = 4
 	DefaultInterval = time.Second
 	DefaultTimeout  = time.Second * 5
+	// DefaultSamples is the number of durations kept for percentiles
+	DefaultSamples = 10000
 )
@@ -0,0 +1,28 @@
+package ping
+
+import (
+	"net"
+	"strings"
+	"syscall"
+)
+
+// Dialer returns a net.Dialer of network bound to SourceIP and Interface.
+func (op *Option) Dialer(network string) *net.Dialer {
+	dialer := &net.Dialer{
+		Resolver: op.Resolver,
+	}
+	if op.SourceIP != nil {
+		if strings.HasPrefix(network, "udp") {
+			dialer.LocalAddr = &net.UDPAddr{IP: op.SourceIP}
+		} else {
+			dialer.LocalAddr = &net.TCPAddr{IP: op.SourceIP}
+		}
+	}
+	if op.Interface != "" {
+		iface := op.Interface
+		dialer.Control = func(network, address string, c syscall.RawConn) error {
+			return bindToDevice(c, iface)
+		}
+	}
+	return dialer
+}
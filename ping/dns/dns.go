@@ -0,0 +1,184 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http/httptrace"
+	"net/url"
+	"time"
+
+	"github.com/cloverstd/tcping/ping"
+)
+
+var _ ping.Ping = (*Ping)(nil)
+
+// DefaultPort is the port of the dns server when it is omitted.
+const DefaultPort = "53"
+
+const maxMessageSize = 64 * 1024
+
+// New parses u like dns://server:53/name?type=A&transport=udp,
+// the name defaults to the root ".", the type to A and the transport to udp.
+func New(u *url.URL, op *ping.Option) (*Ping, error) {
+	port := u.Port()
+	if port == "" {
+		port = DefaultPort
+	}
+	name := u.Path
+	if len(name) > 0 && name[0] == '/' {
+		name = name[1:]
+	}
+	if name == "" {
+		name = "."
+	}
+
+	query := u.Query()
+	qtype := uint16(1)
+	if t := query.Get("type"); t != "" {
+		var err error
+		if qtype, err = ParseType(t); err != nil {
+			return nil, err
+		}
+	}
+	network := "udp"
+	switch transport := query.Get("transport"); transport {
+	case "", "udp":
+	case "tcp":
+		network = "tcp"
+	default:
+		return nil, fmt.Errorf("transport %s not support", transport)
+	}
+	if _, err := newQuery(0, name, qtype); err != nil {
+		return nil, err
+	}
+
+	return &Ping{
+		server:  net.JoinHostPort(u.Hostname(), port),
+		name:    name,
+		qtype:   qtype,
+		network: network,
+		option:  op,
+		dialer:  op.Dialer(network),
+	}, nil
+}
+
+type Ping struct {
+	option  *ping.Option
+	server  string
+	name    string
+	qtype   uint16
+	network string
+	dialer  *net.Dialer
+}
+
+func (p *Ping) Ping(ctx context.Context) *ping.Stats {
+	timeout := ping.DefaultTimeout
+	if p.option.Timeout > 0 {
+		timeout = p.option.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stats := ping.Stats{
+		Meta: map[string]fmt.Stringer{},
+	}
+	var dnsStart time.Time
+	// trace the resolving of the server
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			dnsStart = time.Now()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			stats.DNSDuration = time.Since(dnsStart)
+		},
+	})
+
+	start := time.Now()
+	conn, err := p.dialer.DialContext(ctx, p.option.Network(p.network), p.option.DialAddress(p.server))
+	if err != nil {
+		stats.Duration = time.Since(start)
+		stats.Error = err
+		if oe, ok := err.(*net.OpError); ok && oe.Addr != nil {
+			stats.Address = oe.Addr.String()
+		}
+		return &stats
+	}
+	defer conn.Close()
+	stats.Address = conn.RemoteAddr().String()
+
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	// interrupt the exchange when ctx is canceled
+	go func() {
+		<-ctx.Done()
+		_ = conn.SetDeadline(time.Now())
+	}()
+
+	h, err := p.exchange(conn)
+	stats.Duration = time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = ctx.Err()
+		}
+		stats.Error = err
+		return &stats
+	}
+	stats.Connected = true
+	stats.Meta["rcode"] = h.rcode
+	stats.Meta["answers"] = ping.Int(h.answers)
+	if h.truncated {
+		stats.Meta["truncated"] = ping.Int(1)
+	}
+	return &stats
+}
+
+func (p *Ping) exchange(conn net.Conn) (header, error) {
+	id := uint16(rand.Intn(1 << 16))
+	msg, err := newQuery(id, p.name, p.qtype)
+	if err != nil {
+		return header{}, err
+	}
+
+	var resp []byte
+	if p.network == "tcp" {
+		// the messages over tcp are prefixed with the length
+		prefixed := make([]byte, 2+len(msg))
+		binary.BigEndian.PutUint16(prefixed, uint16(len(msg)))
+		copy(prefixed[2:], msg)
+		if _, err = conn.Write(prefixed); err != nil {
+			return header{}, err
+		}
+		var length [2]byte
+		if _, err = io.ReadFull(conn, length[:]); err != nil {
+			return header{}, err
+		}
+		resp = make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err = io.ReadFull(conn, resp); err != nil {
+			return header{}, err
+		}
+	} else {
+		if _, err = conn.Write(msg); err != nil {
+			return header{}, err
+		}
+		buf := make([]byte, maxMessageSize)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return header{}, err
+		}
+		resp = buf[:n]
+	}
+
+	h, err := parseHeader(resp)
+	if err != nil {
+		return header{}, err
+	}
+	if !h.response || h.id != id {
+		return header{}, errors.New("mismatched dns response")
+	}
+	return h, nil
+}
@@ -0,0 +1,130 @@
+package dns_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+
+	tcping "github.com/cloverstd/tcping/ping"
+	"github.com/cloverstd/tcping/ping/dns"
+)
+
+// answer responds NXDOMAIN for names under "missing." and one A record for others.
+func answer(query []byte) []byte {
+	var labels []string
+	i := 12
+	for query[i] != 0 {
+		n := int(query[i])
+		labels = append(labels, string(query[i+1:i+1+n]))
+		i += n + 1
+	}
+	question := query[12 : i+5]
+
+	resp := make([]byte, 12)
+	copy(resp, query[:2])
+	flags := uint16(1<<15 | 1<<8 | 1<<7)
+	if len(labels) > 0 && labels[0] == "missing" {
+		flags |= 3
+	} else {
+		binary.BigEndian.PutUint16(resp[6:], 1)
+	}
+	binary.BigEndian.PutUint16(resp[2:], flags)
+	binary.BigEndian.PutUint16(resp[4:], 1)
+	resp = append(resp, question...)
+	if flags&3 == 0 {
+		// name pointer, type A, class IN, ttl 60, 127.0.0.1
+		resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4, 127, 0, 0, 1)
+	}
+	return resp
+}
+
+// stub serves answer over udp and tcp on the same port.
+func stub(t *testing.T) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(answer(buf[:n]), addr)
+		}
+	}()
+
+	l, err := net.Listen("tcp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				var length [2]byte
+				if _, err := io.ReadFull(conn, length[:]); err != nil {
+					return
+				}
+				query := make([]byte, binary.BigEndian.Uint16(length[:]))
+				if _, err := io.ReadFull(conn, query); err != nil {
+					return
+				}
+				resp := answer(query)
+				binary.BigEndian.PutUint16(length[:], uint16(len(resp)))
+				_, _ = conn.Write(append(length[:], resp...))
+			}(conn)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestPing(t *testing.T) {
+	server := stub(t)
+	for _, tt := range []struct {
+		query   string
+		rcode   string
+		answers tcping.Int
+	}{
+		{"/example.com?type=A", "NOERROR", 1},
+		{"/example.com?type=AAAA&transport=tcp", "NOERROR", 1},
+		{"/missing.example.com", "NXDOMAIN", 0},
+		{"/missing.example.com?transport=tcp", "NXDOMAIN", 0},
+	} {
+		u, _ := url.Parse("dns://" + server + tt.query)
+		ping, err := dns.New(u, &tcping.Option{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := ping.Ping(context.Background())
+		if !stats.Connected {
+			t.Fatalf("%s: ping failed, %s", tt.query, stats.Error)
+		}
+		if stats.Meta["rcode"].String() != tt.rcode {
+			t.Fatalf("%s: rcode should be %s, got %s", tt.query, tt.rcode, stats.Meta["rcode"])
+		}
+		if stats.Meta["answers"] != tt.answers {
+			t.Fatalf("%s: answers should be %d, got %s", tt.query, tt.answers, stats.Meta["answers"])
+		}
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	for _, query := range []string{"/example.com?type=BOGUS", "/example.com?transport=quic", "/" + strings.Repeat("a", 64)} {
+		u, _ := url.Parse("dns://127.0.0.1" + query)
+		if _, err := dns.New(u, &tcping.Option{}); err == nil {
+			t.Fatalf("%s should be invalid", query)
+		}
+	}
+}
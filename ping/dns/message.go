@@ -0,0 +1,102 @@
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const headerSize = 12
+
+// header flags
+const (
+	flagQR = 1 << 15
+	flagTC = 1 << 9
+	flagRD = 1 << 8
+)
+
+const classINET = 1
+
+var types = map[string]uint16{
+	"A":     1,
+	"NS":    2,
+	"CNAME": 5,
+	"SOA":   6,
+	"PTR":   12,
+	"MX":    15,
+	"TXT":   16,
+	"AAAA":  28,
+	"SRV":   33,
+	"ANY":   255,
+}
+
+// ParseType converts the name or number of a query type, e.g. "AAAA" or "28".
+func ParseType(s string) (uint16, error) {
+	if t, ok := types[strings.ToUpper(s)]; ok {
+		return t, nil
+	}
+	if t, err := strconv.ParseUint(s, 10, 16); err == nil {
+		return uint16(t), nil
+	}
+	return 0, fmt.Errorf("query type %s not support", s)
+}
+
+// RCode is the response code of a DNS response.
+type RCode uint16
+
+var rcodes = []string{"NOERROR", "FORMERR", "SERVFAIL", "NXDOMAIN", "NOTIMP", "REFUSED"}
+
+func (r RCode) String() string {
+	if int(r) < len(rcodes) {
+		return rcodes[r]
+	}
+	return "RCODE" + strconv.Itoa(int(r))
+}
+
+// header is the decoded header of a response.
+type header struct {
+	id        uint16
+	response  bool
+	truncated bool
+	rcode     RCode
+	answers   int
+}
+
+// newQuery encodes a recursive query of name and qtype.
+func newQuery(id uint16, name string, qtype uint16) ([]byte, error) {
+	msg := make([]byte, headerSize, headerSize+len(name)+6)
+	binary.BigEndian.PutUint16(msg[0:], id)
+	binary.BigEndian.PutUint16(msg[2:], flagRD)
+	// one question
+	binary.BigEndian.PutUint16(msg[4:], 1)
+
+	name = strings.TrimSuffix(name, ".")
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("invalid name %s", name)
+			}
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+	}
+	msg = append(msg, 0)
+	msg = append(msg, byte(qtype>>8), byte(qtype), 0, classINET)
+	return msg, nil
+}
+
+func parseHeader(msg []byte) (header, error) {
+	if len(msg) < headerSize {
+		return header{}, errors.New("dns response is too short")
+	}
+	flags := binary.BigEndian.Uint16(msg[2:])
+	return header{
+		id:        binary.BigEndian.Uint16(msg[0:]),
+		response:  flags&flagQR != 0,
+		truncated: flags&flagTC != 0,
+		rcode:     RCode(flags & 0xf),
+		answers:   int(binary.BigEndian.Uint16(msg[6:])),
+	}, nil
+}
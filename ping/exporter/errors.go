@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+)
+
+// the error classes of the error label, the other errors are ClassOther to keep the cardinality bounded
+const (
+	ClassTimeout     = "timeout"
+	ClassDNS         = "dns"
+	ClassRefused     = "refused"
+	ClassReset       = "reset"
+	ClassUnreachable = "unreachable"
+	ClassTLS         = "tls"
+	ClassOther       = "other"
+)
+
+// ErrorClass maps err to one of the fixed error classes.
+func ErrorClass(err error) string {
+	var (
+		netErr      net.Error
+		dnsErr      *net.DNSError
+		recordErr   tls.RecordHeaderError
+		alertErr    tls.AlertError
+		certErr     *tls.CertificateVerificationError
+		unknownErr  x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		invalidErr  x509.CertificateInvalidError
+	)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return ClassTimeout
+	case errors.As(err, &dnsErr):
+		return ClassDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ClassRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return ClassReset
+	case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH):
+		return ClassUnreachable
+	case errors.As(err, &recordErr), errors.As(err, &alertErr), errors.As(err, &certErr),
+		errors.As(err, &unknownErr), errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return ClassTLS
+	}
+	return ClassOther
+}
@@ -0,0 +1,171 @@
+// Package exporter exposes the probe results as Prometheus metrics.
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloverstd/tcping/ping"
+)
+
+// ContentType is the content type of the Prometheus text format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var _ http.Handler = (*Exporter)(nil)
+
+type key struct {
+	target   string
+	protocol string
+}
+
+type series struct {
+	duration *histogram
+	dns      *histogram
+	success  uint64
+	failures map[string]uint64
+	up       bool
+}
+
+// Exporter collects the probe results of several targets.
+type Exporter struct {
+	mu     sync.Mutex
+	series map[key]*series
+}
+
+// New create an empty Exporter.
+func New() *Exporter {
+	return &Exporter{
+		series: map[key]*series{},
+	}
+}
+
+// Reporter returns a ping.Reporter which records the probes of url.
+func (e *Exporter) Reporter(url *url.URL) ping.Reporter {
+	k := key{
+		target:   url.String(),
+		protocol: url.Scheme,
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.getSeries(k)
+	return &reporter{exporter: e, key: k}
+}
+
+func (e *Exporter) getSeries(k key) *series {
+	s, ok := e.series[k]
+	if !ok {
+		s = &series{
+			duration: newHistogram(DefaultBuckets),
+			dns:      newHistogram(DefaultBuckets),
+			failures: map[string]uint64{},
+		}
+		e.series[k] = s
+	}
+	return s
+}
+
+func (e *Exporter) observe(k key, stats *ping.Stats) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	s := e.getSeries(k)
+	s.up = stats.Error == nil
+	if stats.DNSDuration > 0 {
+		s.dns.Observe(stats.DNSDuration.Seconds())
+	}
+	if stats.Error != nil {
+		s.failures[ErrorClass(stats.Error)]++
+		return
+	}
+	s.success++
+	s.duration.Observe(stats.Duration.Seconds())
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	e.Write(w)
+}
+
+// Write writes the metrics in the Prometheus text format to w.
+func (e *Exporter) Write(w io.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys := make([]key, 0, len(e.series))
+	for k := range e.series {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].target < keys[j].target
+	})
+
+	var builder strings.Builder
+	header(&builder, "tcping_probe_up", "gauge", "Whether the last probe was successful.")
+	for _, k := range keys {
+		up := 0
+		if e.series[k].up {
+			up = 1
+		}
+		_, _ = fmt.Fprintf(&builder, "tcping_probe_up{%s} %d\n", k.labels(), up)
+	}
+
+	header(&builder, "tcping_probe_duration_seconds", "histogram", "Duration of the successful probes.")
+	for _, k := range keys {
+		e.series[k].duration.write(&builder, "tcping_probe_duration_seconds", k.labels())
+	}
+
+	header(&builder, "tcping_probe_dns_duration_seconds", "histogram", "Duration of the DNS lookups of the probes.")
+	for _, k := range keys {
+		e.series[k].dns.write(&builder, "tcping_probe_dns_duration_seconds", k.labels())
+	}
+
+	header(&builder, "tcping_probe_success_total", "counter", "Number of successful probes.")
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(&builder, "tcping_probe_success_total{%s} %d\n", k.labels(), e.series[k].success)
+	}
+
+	header(&builder, "tcping_probe_failure_total", "counter", "Number of failed probes by error class.")
+	for _, k := range keys {
+		failures := e.series[k].failures
+		classes := make([]string, 0, len(failures))
+		for class := range failures {
+			classes = append(classes, class)
+		}
+		sort.Strings(classes)
+		for _, class := range classes {
+			_, _ = fmt.Fprintf(&builder, "tcping_probe_failure_total{%s,error=\"%s\"} %d\n", k.labels(), escape(class), failures[class])
+		}
+	}
+	_, _ = io.WriteString(w, builder.String())
+}
+
+func (k key) labels() string {
+	return fmt.Sprintf("target=\"%s\",protocol=\"%s\"", escape(k.target), escape(k.protocol))
+}
+
+func header(w io.Writer, name, typ, help string) {
+	_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+type reporter struct {
+	exporter *Exporter
+	key      key
+}
+
+func (r *reporter) OnProbe(stats *ping.Stats) {
+	r.exporter.observe(r.key, stats)
+}
+
+func (r *reporter) OnSummary(result ping.Result) {}
@@ -0,0 +1,65 @@
+package exporter_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	tcping "github.com/cloverstd/tcping/ping"
+	"github.com/cloverstd/tcping/ping/exporter"
+)
+
+var refused = &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+
+func TestExporter(t *testing.T) {
+	u, _ := url.Parse("tcp://127.0.0.1:80")
+	e := exporter.New()
+	r := e.Reporter(u)
+	r.OnProbe(&tcping.Stats{Connected: true, Duration: 3 * time.Millisecond, DNSDuration: time.Millisecond})
+	r.OnProbe(&tcping.Stats{Error: refused, Duration: time.Millisecond})
+
+	var buf bytes.Buffer
+	e.Write(&buf)
+	metrics := buf.String()
+	for _, line := range []string{
+		`tcping_probe_up{target="tcp://127.0.0.1:80",protocol="tcp"} 0`,
+		`tcping_probe_duration_seconds_bucket{target="tcp://127.0.0.1:80",protocol="tcp",le="0.0025"} 0`,
+		`tcping_probe_duration_seconds_bucket{target="tcp://127.0.0.1:80",protocol="tcp",le="0.005"} 1`,
+		`tcping_probe_duration_seconds_count{target="tcp://127.0.0.1:80",protocol="tcp"} 1`,
+		`tcping_probe_dns_duration_seconds_count{target="tcp://127.0.0.1:80",protocol="tcp"} 1`,
+		`tcping_probe_success_total{target="tcp://127.0.0.1:80",protocol="tcp"} 1`,
+		`tcping_probe_failure_total{target="tcp://127.0.0.1:80",protocol="tcp",error="refused"} 1`,
+	} {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Fatalf("metrics should contain %s, got\n%s", line, metrics)
+		}
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	for _, tt := range []struct {
+		err   error
+		class string
+	}{
+		{refused, exporter.ClassRefused},
+		{&url.Error{Op: "Get", URL: "http://127.0.0.1:1", Err: refused}, exporter.ClassRefused},
+		{context.DeadlineExceeded, exporter.ClassTimeout},
+		{fmt.Errorf("read body failed, %w", os.ErrDeadlineExceeded), exporter.ClassTimeout},
+		{&net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, exporter.ClassDNS},
+		{&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, exporter.ClassReset},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.EHOSTUNREACH)}, exporter.ClassUnreachable},
+		{errors.New("expect status 200, got 503 at 2026-10-18 10:00:00"), exporter.ClassOther},
+	} {
+		if class := exporter.ErrorClass(tt.err); class != tt.class {
+			t.Errorf("the class of %v should be %s, got %s", tt.err, tt.class, class)
+		}
+	}
+}
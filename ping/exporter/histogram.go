@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the duration histograms.
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+// Observe records v in the first bucket it fits, the buckets are made cumulative on write.
+func (h *histogram) Observe(v float64) {
+	h.count++
+	h.sum += v
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+func (h *histogram) write(w io.Writer, name string, labels string) {
+	var cumulative uint64
+	for i, bound := range h.buckets {
+		cumulative += h.counts[i]
+		_, _ = fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(bound), cumulative)
+	}
+	_, _ = fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	_, _ = fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	_, _ = fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
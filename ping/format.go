@@ -0,0 +1,158 @@
+package ping
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format is the output format of Pinger.
+type Format int
+
+const (
+	// FormatText is the human readable output
+	FormatText Format = iota
+	// FormatJSON writes a single JSON document with all probes and the summary
+	FormatJSON
+	// FormatNDJSON writes one JSON object per line for every probe and the summary
+	FormatNDJSON
+)
+
+func (format Format) String() string {
+	switch format {
+	case FormatText:
+		return "text"
+	case FormatJSON:
+		return "json"
+	case FormatNDJSON:
+		return "ndjson"
+	}
+	return "unknown"
+}
+
+// ParseFormat convert format string to Format
+func ParseFormat(format string) (Format, error) {
+	switch strings.ToLower(format) {
+	case "", FormatText.String():
+		return FormatText, nil
+	case FormatJSON.String():
+		return FormatJSON, nil
+	case FormatNDJSON.String():
+		return FormatNDJSON, nil
+	}
+	return 0, fmt.Errorf("output format %s not support", format)
+}
+
+type statsJSON struct {
+	Connected   bool                   `json:"connected"`
+	Error       string                 `json:"error,omitempty"`
+	Duration    time.Duration          `json:"duration"`
+	DNSDuration time.Duration          `json:"dns_duration"`
+	Address     string                 `json:"address"`
+	Meta        map[string]interface{} `json:"meta,omitempty"`
+	Extra       interface{}            `json:"extra,omitempty"`
+	Warning     string                 `json:"warning,omitempty"`
+}
+
+func (s *Stats) toJSON() statsJSON {
+	v := statsJSON{
+		Connected:   s.Connected,
+		Duration:    s.Duration,
+		DNSDuration: s.DNSDuration,
+		Address:     s.Address,
+		Warning:     s.Warning,
+	}
+	if s.Error != nil {
+		v.Error = s.Error.Error()
+	}
+	if len(s.Meta) > 0 {
+		v.Meta = make(map[string]interface{}, len(s.Meta))
+		for key, value := range s.Meta {
+			v.Meta[key] = jsonValue(value)
+		}
+	}
+	if s.Extra != nil {
+		v.Extra = jsonValue(s.Extra)
+	}
+	return v
+}
+
+// MarshalJSON encodes the error as string, meta and extra are kept structured
+// when they implement json.Marshaler.
+func (s *Stats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.toJSON())
+}
+
+func jsonValue(v fmt.Stringer) interface{} {
+	if d, ok := v.(time.Duration); ok {
+		// nanoseconds like the other durations
+		return int64(d)
+	}
+	if m, ok := v.(json.Marshaler); ok {
+		return m
+	}
+	return strings.TrimSpace(v.String())
+}
+
+type probeJSON struct {
+	Type   string    `json:"type"`
+	Target string    `json:"target"`
+	Seq    int       `json:"seq"`
+	Time   time.Time `json:"time"`
+	statsJSON
+}
+
+type summaryJSON struct {
+	Type        string        `json:"type"`
+	Target      string        `json:"target"`
+	IP          string        `json:"ip,omitempty"`
+	Total       int           `json:"total"`
+	Successful  int           `json:"successful"`
+	Failed      int           `json:"failed"`
+	Warnings    int           `json:"warnings"`
+	MinDuration time.Duration `json:"min"`
+	MaxDuration time.Duration `json:"max"`
+	AvgDuration time.Duration `json:"avg"`
+	P50         time.Duration `json:"p50"`
+	P90         time.Duration `json:"p90"`
+	P95         time.Duration `json:"p95"`
+	P99         time.Duration `json:"p99"`
+	StdDev      time.Duration `json:"stddev"`
+	Jitter      time.Duration `json:"jitter"`
+}
+
+type documentJSON struct {
+	Target  string      `json:"target"`
+	Probes  []probeJSON `json:"probes"`
+	Summary summaryJSON `json:"summary"`
+}
+
+// MarshalJSON ...
+func (result Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(result.toJSON())
+}
+
+func (result Result) toJSON() summaryJSON {
+	v := summaryJSON{
+		Type:        "summary",
+		Total:       result.Counter,
+		Successful:  result.SuccessCounter,
+		Failed:      result.Failed(),
+		Warnings:    result.WarningCounter,
+		MinDuration: result.MinDuration,
+		MaxDuration: result.MaxDuration,
+		AvgDuration: result.Avg(),
+		P50:         result.P50,
+		P90:         result.P90,
+		P95:         result.P95,
+		P99:         result.P99,
+		StdDev:      result.StdDev,
+		Jitter:      result.Jitter,
+	}
+	if result.Target != nil {
+		v.Target = result.Target.String()
+		v.IP = result.Target.IP
+	}
+	return v
+}
@@ -0,0 +1,116 @@
+package ping
+
+import (
+	"io"
+	"sync"
+)
+
+// Runner is a Pinger or a group of Pingers which runs until it is done or stopped.
+type Runner interface {
+	Ping()
+	Stop()
+}
+
+// Group runs several Pingers concurrently.
+type Group struct {
+	runners []Runner
+
+	stopOnce sync.Once
+	stopC    chan struct{}
+	// finishedC is closed when Ping returns
+	finishedC chan struct{}
+}
+
+// NewGroup create a Group of pingers.
+func NewGroup(pingers ...*Pinger) *Group {
+	runners := make([]Runner, 0, len(pingers))
+	for _, p := range pingers {
+		runners = append(runners, p)
+	}
+	return NewRunnerGroup(runners...)
+}
+
+// NewRunnerGroup create a Group of runners, e.g. AddressGroup.
+func NewRunnerGroup(runners ...Runner) *Group {
+	return &Group{
+		runners:   runners,
+		stopC:     make(chan struct{}),
+		finishedC: make(chan struct{}),
+	}
+}
+
+// Ping starts all runners and waits until all of them are done.
+func (g *Group) Ping() {
+	defer close(g.finishedC)
+	defer g.Stop()
+
+	var wg sync.WaitGroup
+	for _, r := range g.runners {
+		wg.Add(1)
+		go func(r Runner) {
+			defer wg.Done()
+			r.Ping()
+		}(r)
+	}
+	wg.Wait()
+}
+
+// Stop stops all runners.
+func (g *Group) Stop() {
+	g.stopOnce.Do(func() {
+		for _, r := range g.runners {
+			r.Stop()
+		}
+		close(g.stopC)
+	})
+}
+
+// Done is closed when all runners are done or the group is stopped.
+func (g *Group) Done() <-chan struct{} {
+	return g.stopC
+}
+
+// Wait waits until Ping returns, so the results are not written by the runners any more.
+func (g *Group) Wait() {
+	<-g.finishedC
+}
+
+// Pingers returns the pingers of the group, including the pingers of the AddressGroups.
+func (g *Group) Pingers() []*Pinger {
+	var pingers []*Pinger
+	for _, r := range g.runners {
+		switch r := r.(type) {
+		case *Pinger:
+			pingers = append(pingers, r)
+		case *AddressGroup:
+			pingers = append(pingers, r.Pingers()...)
+		}
+	}
+	return pingers
+}
+
+// Results returns the result of every pinger.
+func (g *Group) Results() []Result {
+	pingers := g.Pingers()
+	results := make([]Result, 0, len(pingers))
+	for _, p := range pingers {
+		results = append(results, p.Result())
+	}
+	return results
+}
+
+// SyncWriter serializes the writes to w, so the output of concurrent pingers is not mixed up.
+func SyncWriter(w io.Writer) io.Writer {
+	return &syncWriter{w: w}
+}
+
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
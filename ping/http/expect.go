@@ -0,0 +1,144 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultExpectBodySize is the size of the body matched by Expect.Body.
+const DefaultExpectBodySize = 1 << 20
+
+// Expect is the assertions of the response, the probe fails when one of them is not met.
+type Expect struct {
+	// Status is the accepted status codes, any status is accepted when it is empty
+	Status []StatusRange
+	// Body matches the first DefaultExpectBodySize bytes of the body
+	Body *regexp.Regexp
+	// Headers are matched by all the values of the header
+	Headers []HeaderMatch
+}
+
+// StatusRange is an inclusive range of status codes.
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+func (r StatusRange) String() string {
+	if r.Min == r.Max {
+		return strconv.Itoa(r.Min)
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// ParseStatus parses the status codes like 200-299,301.
+func ParseStatus(s string) ([]StatusRange, error) {
+	var ranges []StatusRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		min, max := part, part
+		if i := strings.Index(part, "-"); i >= 0 {
+			min, max = part[:i], part[i+1:]
+		}
+		r := StatusRange{}
+		var err error
+		if r.Min, err = strconv.Atoi(min); err != nil {
+			return nil, fmt.Errorf("invalid status %s", part)
+		}
+		if r.Max, err = strconv.Atoi(max); err != nil {
+			return nil, fmt.Errorf("invalid status %s", part)
+		}
+		if r.Min < 100 || r.Max > 999 || r.Min > r.Max {
+			return nil, fmt.Errorf("invalid status %s", part)
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// HeaderMatch matches the values of the header Name by Value, the header only needs to be present when Value is nil.
+type HeaderMatch struct {
+	Name  string
+	Value *regexp.Regexp
+}
+
+func (m HeaderMatch) String() string {
+	if m.Value == nil {
+		return m.Name
+	}
+	return fmt.Sprintf("%s: %s", m.Name, m.Value)
+}
+
+// ParseHeaderMatch parses the header match like "Content-Type: json", the value is a regular expression.
+func ParseHeaderMatch(s string) (HeaderMatch, error) {
+	name, value := s, ""
+	if i := strings.Index(s, ":"); i >= 0 {
+		name, value = s[:i], strings.TrimSpace(s[i+1:])
+	}
+	m := HeaderMatch{Name: strings.TrimSpace(name)}
+	if m.Name == "" {
+		return m, fmt.Errorf("invalid header %s", s)
+	}
+	if value != "" {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return m, fmt.Errorf("invalid header %s, %w", s, err)
+		}
+		m.Value = re
+	}
+	return m, nil
+}
+
+// readBody reports whether the body is needed by the assertions.
+func (e *Expect) readBody() bool {
+	return e != nil && e.Body != nil
+}
+
+// Check returns the first assertion not met by the response, body is the beginning of the response body when Body is set.
+func (e *Expect) Check(resp *http.Response, body []byte) error {
+	if e == nil {
+		return nil
+	}
+	if len(e.Status) > 0 {
+		matched := false
+		for _, r := range e.Status {
+			if resp.StatusCode >= r.Min && resp.StatusCode <= r.Max {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			expected := make([]string, 0, len(e.Status))
+			for _, r := range e.Status {
+				expected = append(expected, r.String())
+			}
+			return fmt.Errorf("expect status %s, got %d", strings.Join(expected, ","), resp.StatusCode)
+		}
+	}
+	for _, m := range e.Headers {
+		values := resp.Header.Values(m.Name)
+		if len(values) == 0 {
+			return fmt.Errorf("expect header %s, not found", m.Name)
+		}
+		if m.Value == nil {
+			continue
+		}
+		matched := false
+		for _, value := range values {
+			if m.Value.MatchString(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("expect header %s, got %s", m, strings.Join(values, ","))
+		}
+	}
+	if e.Body != nil && !e.Body.Match(body) {
+		return fmt.Errorf("expect body %s, not matched", e.Body)
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+package http
+
+// ClosedReason returns the reason of the connection close which is not reported yet in keepalive mode.
+func (p *Ping) ClosedReason() string {
+	p.tracker.mu.Lock()
+	defer p.tracker.mu.Unlock()
+	return p.tracker.reason
+}
@@ -1,23 +1,67 @@
 package http
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	pkgurl "net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cloverstd/tcping/ping"
+	"golang.org/x/net/http2"
 )
 
 var _ ping.Ping = (*Ping)(nil)
 
+// Config is the options of the HTTP request and the response.
+type Config struct {
+	Method string
+	// Trace reports the Trace of every request as Extra
+	Trace bool
+	// Expect is the assertions of the response, nil is any response
+	Expect *Expect
+
+	// Header is added to the request, it overrides the User-Agent of the Option
+	Header http.Header
+	// Body is sent with every request
+	Body []byte
+	// BasicAuth is the "user:password" of the basic authentication
+	BasicAuth string
+	// BearerToken is sent as "Authorization: Bearer token"
+	BearerToken string
+
+	// Version is the HTTP version 1.1, 2 or 3, the default is 1.1
+	Version string
+	// TLSConfig is the TLS configuration of https, nil is the default
+	TLSConfig *tls.Config
+
+	// KeepAlive reuses the connection across the probes and reports the reconnects
+	KeepAlive bool
+
+	// MaxBodyBytes stops reading the body after the bytes, 0 reads the whole body
+	MaxBodyBytes int64
+	// Range requests the byte range like 0-1023 with the Range header
+	Range string
+
+	// FollowRedirects is the maximum redirects to follow, the redirect is the response when it is 0
+	FollowRedirects int
+}
+
 func New(method string, url string, op *ping.Option, trace bool) (*Ping, error) {
+	return NewWithConfig(url, op, &Config{Method: method, Trace: trace})
+}
 
-	_, err := http.NewRequest(method, url, nil)
+// NewWithConfig creates the Ping of url with config.
+func NewWithConfig(url string, op *ping.Option, config *Config) (*Ping, error) {
+	method := config.Method
+	req, err := http.NewRequest(method, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("url or method is invalid, %w", err)
 	}
@@ -25,42 +69,182 @@ func New(method string, url string, op *ping.Option, trace bool) (*Ping, error)
 	if method == "" {
 		method = http.MethodGet
 	}
+	if config.BasicAuth != "" && config.BearerToken != "" {
+		return nil, errors.New("basic auth and bearer token can not be used together")
+	}
+	if config.BasicAuth != "" && !strings.Contains(config.BasicAuth, ":") {
+		return nil, fmt.Errorf("invalid basic auth, it should be user:password")
+	}
+	if config.MaxBodyBytes < 0 {
+		return nil, fmt.Errorf("invalid max body bytes %d", config.MaxBodyBytes)
+	}
+	if config.Range != "" && !validRange(config.Range) {
+		return nil, fmt.Errorf("invalid range %s, it should be like 0-1023, 1024- or -1024", config.Range)
+	}
+
+	var tracker *connTracker
+	if config.KeepAlive {
+		tracker = &connTracker{}
+	}
+	transport, err := newTransport(req.URL, op, config, tracker)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Ping{
-		url:    url,
-		method: method,
-		trace:  trace,
-		option: op,
+		url:       url,
+		method:    method,
+		trace:     config.Trace,
+		expect:    config.Expect,
+		config:    config,
+		host:      req.URL.Host,
+		option:    op,
+		tracker:   tracker,
+		transport: transport,
 		client: &http.Client{
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				// disable redirect
 				return http.ErrUseLastResponse
 			},
-			Transport: &http.Transport{
-				Proxy: func(r *http.Request) (*pkgurl.URL, error) {
-					if op.Proxy != nil {
-						return op.Proxy, nil
-					}
-					return http.ProxyFromEnvironment(r)
-				},
-				DialContext: (&net.Dialer{
-					Resolver: op.Resolver,
-				}).DialContext,
-				DisableKeepAlives: true,
-				ForceAttemptHTTP2: false,
-			},
+			Transport: transport,
 		},
 	}, nil
 }
 
+// newTransport creates the transport of the HTTP version, HTTP/2 is negotiated by ALPN over TLS
+// or h2c with prior knowledge over cleartext, HTTP/3 runs over QUIC.
+// Every scheme has its own round tripper since a redirect may change the scheme,
+// the error of the scheme of u is returned.
+// The connections are kept alive and reported to the tracker when it is not nil.
+func newTransport(u *pkgurl.URL, op *ping.Option, config *Config, tracker *connTracker) (*transport, error) {
+	dialer := op.Dialer("tcp")
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var (
+			conn       net.Conn
+			proxyStats *ping.ProxyStats
+			err        error
+		)
+		if ping.IsSOCKS(op.Proxy) {
+			conn, proxyStats, err = ping.DialProxy(ctx, dialer, op, addr)
+			if s, ok := ctx.Value(proxyStatsKey{}).(*ping.ProxyStats); ok && proxyStats != nil {
+				*s = *proxyStats
+			}
+		} else {
+			conn, err = dialer.DialContext(ctx, op.Network(network), op.DialAddress(addr))
+		}
+		if err != nil || tracker == nil {
+			return conn, err
+		}
+		return newTrackedConn(conn, tracker), nil
+	}
+	proxy := func(r *http.Request) (*pkgurl.URL, error) {
+		if ping.IsSOCKS(op.Proxy) {
+			// dialed by DialContext
+			return nil, nil
+		}
+		if op.Proxy != nil {
+			return op.Proxy, nil
+		}
+		return http.ProxyFromEnvironment(r)
+	}
+
+	t := &transport{
+		schemes: map[string]http.RoundTripper{},
+		errs:    map[string]error{},
+	}
+	switch config.Version {
+	case "", "1.1":
+		rt := &http.Transport{
+			Proxy:             proxy,
+			DialContext:       dial,
+			TLSClientConfig:   config.TLSConfig,
+			DisableKeepAlives: tracker == nil,
+			IdleConnTimeout:   DefaultIdleTimeout,
+			ForceAttemptHTTP2: false,
+		}
+		t.schemes["http"] = rt
+		t.schemes["https"] = rt
+	case "2":
+		t.schemes["https"] = &http.Transport{
+			Proxy:             proxy,
+			DialContext:       dial,
+			TLSClientConfig:   config.TLSConfig,
+			DisableKeepAlives: tracker == nil,
+			IdleConnTimeout:   DefaultIdleTimeout,
+			ForceAttemptHTTP2: true,
+		}
+		if op.Proxy != nil && !ping.IsSOCKS(op.Proxy) {
+			t.errs["http"] = errors.New("h2c can not be used with http proxy")
+			break
+		}
+		t.schemes["http"] = &http2.Transport{
+			AllowHTTP:       true,
+			IdleConnTimeout: DefaultIdleTimeout,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				// h2c with prior knowledge
+				return dial(ctx, network, addr)
+			},
+		}
+	case "3":
+		if op.Proxy != nil {
+			return nil, errors.New("http/3 can not be used with proxy")
+		}
+		t.schemes["https"] = newHTTP3Transport(op, config.TLSConfig, tracker)
+		t.errs["http"] = errors.New("http/3 requires https")
+	default:
+		return nil, fmt.Errorf("http version %s not support, it should be 1.1, 2 or 3", config.Version)
+	}
+	if err := t.errs[u.Scheme]; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// transport sends every request with the round tripper of its scheme.
+type transport struct {
+	schemes map[string]http.RoundTripper
+	// errs is the reason why a scheme can not be requested with the HTTP version
+	errs map[string]error
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt, ok := t.schemes[req.URL.Scheme]; ok {
+		return rt.RoundTrip(req)
+	}
+	if err, ok := t.errs[req.URL.Scheme]; ok {
+		return nil, err
+	}
+	return nil, fmt.Errorf("scheme %s not support", req.URL.Scheme)
+}
+
+// Close closes the connections of all round trippers,
+// the HTTP/3 transport also closes the connection which is still dialing.
+func (t *transport) Close() {
+	for _, rt := range t.schemes {
+		if closer, ok := rt.(io.Closer); ok {
+			_ = closer.Close()
+		} else if idle, ok := rt.(interface{ CloseIdleConnections() }); ok {
+			idle.CloseIdleConnections()
+		}
+	}
+}
+
 type Ping struct {
-	client *http.Client
-	trace  bool
+	client    *http.Client
+	transport *transport
+	trace     bool
 
 	option *ping.Option
 	method string
+	expect *Expect
+	config *Config
 
 	url string
+	// host is the host of url, see do
+	host string
+
+	// tracker records the reconnects in keepalive mode, it is nil without keepalive
+	tracker *connTracker
 }
 
 func (p *Ping) Ping(ctx context.Context) *ping.Stats {
@@ -73,45 +257,250 @@ func (p *Ping) Ping(ctx context.Context) *ping.Stats {
 	stats := ping.Stats{
 		Meta: map[string]fmt.Stringer{},
 	}
-	trace := Trace{}
-	if p.trace {
-		stats.Extra = &trace
-	}
+	var proxyStats ping.ProxyStats
+	ctx = context.WithValue(ctx, proxyStatsKey{}, &proxyStats)
+	defer p.closeConnections()
+
+	var (
+		hops    Hops
+		visited = map[string]bool{p.url: true}
+		method  = p.method
+		body    = p.config.Body
+	)
 	start := time.Now()
-	req, err := http.NewRequestWithContext(trace.WithTrace(ctx), p.method, p.url, nil)
+	url := p.url
+	for {
+		trace := &Trace{}
+		hop := &Hop{URL: url, Trace: trace}
+		hops = append(hops, hop)
+		hopStart := time.Now()
+		resp, err := p.do(trace.WithTrace(ctx), method, url, body)
+		stats.DNSDuration += trace.lookup()
+		stats.Address = trace.remoteAddress()
+		if ping.IsSOCKS(p.option.Proxy) && trace.connected() {
+			// a reused connection is not dialed through the proxy
+			proxyStats.SetMeta(stats.Meta)
+		}
+		if p.tracker != nil && len(hops) == 1 {
+			p.trackConnection(&stats, trace, resp, err)
+		}
+		if err != nil {
+			trace.done(0)
+			hop.Duration = time.Since(hopStart)
+			stats.Error = err
+			break
+		}
+		trace.responded()
+
+		location, redirect := p.redirect(resp)
+		if !redirect {
+			p.readResponse(&stats, resp, trace)
+			hop.Status = resp.StatusCode
+			hop.Duration = time.Since(hopStart)
+			break
+		}
+		n, _ := io.Copy(io.Discard, p.bodyReader(resp))
+		resp.Body.Close()
+		trace.done(n)
+		hop.Status = resp.StatusCode
+		hop.Duration = time.Since(hopStart)
+
+		url = location.String()
+		if len(hops) > p.config.FollowRedirects {
+			stats.Error = fmt.Errorf("stopped after %d redirects", p.config.FollowRedirects)
+			break
+		}
+		if visited[url] {
+			stats.Error = fmt.Errorf("redirect loop detected at %s", url)
+			break
+		}
+		visited[url] = true
+		if resp.StatusCode == http.StatusSeeOther ||
+			((resp.StatusCode == http.StatusMovedPermanently || resp.StatusCode == http.StatusFound) && method == http.MethodPost) {
+			// the same as net/http, the redirect is requested with GET without body
+			method = http.MethodGet
+			body = nil
+		}
+	}
+	stats.Duration = time.Since(start)
+
+	if p.config.FollowRedirects > 0 {
+		stats.Meta["redirects"] = ping.Int(len(hops) - 1)
+		if p.trace || len(hops) > 1 {
+			stats.Extra = hops
+		}
+	} else if p.trace {
+		stats.Extra = hops[0].Trace
+	}
+	return &stats
+}
+
+// trackConnection reports whether the connection of the probe is reused, and the reason of a reconnect.
+func (p *Ping) trackConnection(stats *ping.Stats, trace *Trace, resp *http.Response, err error) {
+	if !trace.connected() {
+		stats.Meta["connection"] = ping.String("reused")
+	} else {
+		stats.Meta["connection"] = ping.String("new")
+		if reason := p.tracker.reconnect(); reason != "" {
+			stats.Meta["reconnect"] = ping.String(reason)
+		}
+	}
 	if err != nil {
-		stats.Error = err
-		return &stats
+		p.tracker.closed(ReasonError)
+	} else if resp.Close {
+		// Connection: close
+		p.tracker.closed(ReasonServerClose)
 	}
-	req.Header.Set("user-agent", p.option.UA)
-	resp, err := p.client.Do(req)
-	stats.DNSDuration = trace.DNSDuration
-	stats.Address = trace.address
+}
+
+// closeConnections closes the connections after a probe, every probe opens a new connection without keepalive.
+func (p *Ping) closeConnections() {
+	if p.tracker != nil {
+		// reused by the next probe
+		return
+	}
+	p.transport.Close()
+}
 
+// readResponse reads the body of the final response and checks the assertions.
+func (p *Ping) readResponse(stats *ping.Stats, resp *http.Response, trace *Trace) {
+	defer resp.Body.Close()
+	stats.Meta["status"] = ping.Int(resp.StatusCode)
+	if p.config.Version != "" {
+		stats.Meta["proto"] = ping.String(resp.Proto)
+	}
+	stats.Connected = true
+	if resp.TLS != nil {
+		ping.CheckCertificates(stats, resp.TLS.PeerCertificates, p.option.CertWarnDays)
+	}
+	var (
+		body   bytes.Buffer
+		n      int64
+		err    error
+		reader = p.bodyReader(resp)
+	)
+	if p.expect.readBody() {
+		n, err = io.CopyN(&body, reader, DefaultExpectBodySize)
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	if err == nil {
+		var rest int64
+		rest, err = io.Copy(io.Discard, reader)
+		n += rest
+	}
+	trace.done(n)
+	if n > 0 {
+		stats.Meta["bytes"] = ping.Int(n)
+		stats.Meta["throughput"] = Throughput(trace.Throughput)
+	}
+	if err == nil && p.config.MaxBodyBytes > 0 && n == p.config.MaxBodyBytes {
+		// the rest of the body is not downloaded
+		if _, err := io.ReadFull(resp.Body, make([]byte, 1)); err == nil {
+			stats.Meta["truncated"] = ping.String("true")
+		}
+	}
 	if err != nil {
+		stats.Connected = false
+		stats.Error = fmt.Errorf("read body failed, %w", err)
+	} else if err := p.expect.Check(resp, body.Bytes()); err != nil {
+		stats.Connected = false
 		stats.Error = err
-		stats.Duration = time.Since(start)
-	} else {
-		stats.Meta["status"] = Int(resp.StatusCode)
-		stats.Connected = true
-		bodyStart := time.Now()
-		defer resp.Body.Close()
-		n, err := io.Copy(io.Discard, resp.Body)
-		trace.BodyDuration = time.Since(bodyStart)
-		if n > 0 {
-			stats.Meta["bytes"] = Int(n)
-		}
-		stats.Duration = time.Since(start)
-		if err != nil {
-			stats.Connected = false
-			stats.Error = fmt.Errorf("read body failed, %w", err)
+	}
+}
+
+// bodyReader returns the body of the response limited to MaxBodyBytes.
+func (p *Ping) bodyReader(resp *http.Response) io.Reader {
+	if p.config.MaxBodyBytes > 0 {
+		return io.LimitReader(resp.Body, p.config.MaxBodyBytes)
+	}
+	return resp.Body
+}
+
+// validRange reports whether r is a byte range like 0-1023, 1024- or -1024.
+func validRange(r string) bool {
+	first, last, ok := strings.Cut(r, "-")
+	if !ok || (first == "" && last == "") {
+		return false
+	}
+	for _, s := range []string{first, last} {
+		if _, err := strconv.ParseUint(s, 10, 64); s != "" && err != nil {
+			return false
 		}
 	}
-	return &stats
+	if first != "" && last != "" {
+		start, _ := strconv.ParseUint(first, 10, 64)
+		end, _ := strconv.ParseUint(last, 10, 64)
+		return start <= end
+	}
+	return true
+}
+
+// redirect returns the location to follow when the response is a redirect and FollowRedirects is enabled.
+func (p *Ping) redirect(resp *http.Response) (*pkgurl.URL, bool) {
+	if p.config.FollowRedirects <= 0 {
+		return nil, false
+	}
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return nil, false
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return nil, false
+	}
+	return location, true
 }
 
-type Int int
+// ParseHeader parses the request header like "Name: value".
+func ParseHeader(s string) (name, value string, err error) {
+	i := strings.Index(s, ":")
+	if i <= 0 {
+		return "", "", fmt.Errorf("invalid header %s, it should be Name: value", s)
+	}
+	return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), nil
+}
 
-func (i Int) String() string {
-	return strconv.Itoa(int(i))
+// do sends the request of a hop with the headers, body and authentication of the Config,
+// the Host header and the authentication are only sent to the host of the target.
+func (p *Ping) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	sameHost := req.URL.Host == p.host
+	req.Header.Set("user-agent", p.option.UA)
+	for name, values := range p.config.Header {
+		if strings.EqualFold(name, "Host") {
+			if sameHost {
+				req.Host = values[0]
+			}
+			continue
+		}
+		req.Header[name] = values
+	}
+	if sameHost && p.config.BasicAuth != "" {
+		user, password, _ := strings.Cut(p.config.BasicAuth, ":")
+		req.SetBasicAuth(user, password)
+	}
+	if sameHost && p.config.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.BearerToken)
+	}
+	if p.config.Range != "" {
+		req.Header.Set("Range", "bytes="+p.config.Range)
+	}
+	return p.client.Do(req)
 }
+
+// proxyStatsKey is the context key of the *ping.ProxyStats of a request.
+type proxyStatsKey struct{}
+
+// Int is kept for compatibility, use ping.Int instead.
+type Int = ping.Int
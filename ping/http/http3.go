@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+
+	"github.com/cloverstd/tcping/ping"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// newHTTP3Transport creates the HTTP/3 transport which dials QUIC with the address options of op,
+// the connections are reported to the tracker when it is not nil.
+func newHTTP3Transport(op *ping.Option, tlsConfig *tls.Config, tracker *connTracker) *http3.Transport {
+	return &http3.Transport{
+		TLSClientConfig: tlsConfig,
+		Dial: func(ctx context.Context, addr string, tlsConf *tls.Config, conf *quic.Config) (quic.EarlyConnection, error) {
+			conn, err := dialQUIC(ctx, op, addr, tlsConf, conf)
+			if err == nil && tracker != nil {
+				go trackQUIC(conn, tracker)
+			}
+			return conn, err
+		},
+	}
+}
+
+// dialQUIC connects to addr with QUIC from a new UDP socket, the socket is closed with the connection.
+// The QUIC handshake is reported as the connect of the trace.
+func dialQUIC(ctx context.Context, op *ping.Option, addr string, tlsConf *tls.Config, conf *quic.Config) (quic.EarlyConnection, error) {
+	host, port, err := net.SplitHostPort(op.DialAddress(addr))
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := ping.Resolve(ctx, op.Resolver, host, op.IPVersion)
+	if err != nil {
+		return nil, err
+	}
+	raddr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(addrs[0], port))
+	if err != nil {
+		return nil, err
+	}
+
+	laddr := ":0"
+	if op.SourceIP != nil {
+		laddr = net.JoinHostPort(op.SourceIP.String(), "0")
+	}
+	lc := net.ListenConfig{Control: op.Dialer("udp").Control}
+	conn, err := lc.ListenPacket(ctx, "udp", laddr)
+	if err != nil {
+		return nil, err
+	}
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.ConnectStart != nil {
+		trace.ConnectStart("udp", raddr.String())
+	}
+	qconn, err := quic.DialEarly(ctx, conn, raddr, tlsConf, conf)
+	if err == nil {
+		select {
+		case <-qconn.HandshakeComplete():
+		case <-ctx.Done():
+			_ = qconn.CloseWithError(0, "")
+			err = ctx.Err()
+		}
+	}
+	if trace != nil && trace.ConnectDone != nil {
+		trace.ConnectDone("udp", raddr.String(), err)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go func() {
+		<-qconn.Context().Done()
+		conn.Close()
+	}()
+	return qconn, nil
+}
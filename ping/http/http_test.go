@@ -2,9 +2,24 @@ package http_test
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	nethttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
 	tcping "github.com/cloverstd/tcping/ping"
 	"github.com/cloverstd/tcping/ping/http"
-	"testing"
+	"github.com/quic-go/quic-go/http3"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 func TestPing(t *testing.T) {
@@ -54,3 +69,514 @@ func TestPingRedirect(t *testing.T) {
 		t.Fatal("it should not be redirect")
 	}
 }
+
+func TestPingExpect(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.URL.Path == "/down" {
+			w.WriteHeader(nethttp.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("<h1>Service Unavailable</h1>"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	status, _ := http.ParseStatus("200-299")
+	header, _ := http.ParseHeaderMatch("Content-Type: json")
+	cases := []struct {
+		path   string
+		expect *http.Expect
+		err    string
+	}{
+		{"/", &http.Expect{Status: status}, ""},
+		{"/down", nil, ""},
+		{"/down", &http.Expect{Status: status}, "expect status 200-299, got 503"},
+		{"/", &http.Expect{Body: regexp.MustCompile(`"status":"ok"`)}, ""},
+		{"/down", &http.Expect{Body: regexp.MustCompile(`"status":"ok"`)}, `expect body "status":"ok", not matched`},
+		{"/", &http.Expect{Headers: []http.HeaderMatch{header}}, "expect header Content-Type: json, got text/html"},
+		{"/", &http.Expect{Headers: []http.HeaderMatch{{Name: "X-Missing"}}}, "expect header X-Missing, not found"},
+	}
+	for _, c := range cases {
+		ping, err := http.NewWithConfig(server.URL+c.path, &tcping.Option{}, &http.Config{Expect: c.expect})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := ping.Ping(context.Background())
+		if c.err == "" {
+			if stats.Error != nil {
+				t.Fatalf("%s should be successful, %s", c.path, stats.Error)
+			}
+			continue
+		}
+		if stats.Error == nil || stats.Error.Error() != c.err {
+			t.Fatalf("%s should be failed with %q, got %v", c.path, c.err, stats.Error)
+		}
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	ranges, err := http.ParseStatus("200-299, 301")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ranges) != 2 || ranges[0] != (http.StatusRange{Min: 200, Max: 299}) || ranges[1] != (http.StatusRange{Min: 301, Max: 301}) {
+		t.Fatalf("unexpected ranges %v", ranges)
+	}
+	for _, s := range []string{"", "abc", "299-200", "20-30", "200-"} {
+		if _, err := http.ParseStatus(s); err == nil {
+			t.Fatalf("%q should be invalid", s)
+		}
+	}
+}
+
+func TestPingRequest(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		body, _ := io.ReadAll(r.Body)
+		user, password, _ := r.BasicAuth()
+		if r.Method != nethttp.MethodPost || string(body) != `{"ping":true}` ||
+			r.Header.Get("X-Token") != "a" || r.Host != "api.internal" || r.UserAgent() != "probe" ||
+			user != "user" || password != "secret" {
+			w.WriteHeader(nethttp.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	status, _ := http.ParseStatus("200")
+	config := &http.Config{
+		Method:    nethttp.MethodPost,
+		Expect:    &http.Expect{Status: status},
+		Header:    nethttp.Header{"X-Token": {"a"}, "Host": {"api.internal"}, "User-Agent": {"probe"}},
+		Body:      []byte(`{"ping":true}`),
+		BasicAuth: "user:secret",
+	}
+	ping, err := http.NewWithConfig(server.URL, &tcping.Option{UA: "tcping"}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the body is sent by every probe
+	for i := 0; i < 2; i++ {
+		if stats := ping.Ping(context.Background()); stats.Error != nil {
+			t.Fatal(stats.Error)
+		}
+	}
+
+	config.BearerToken = "token"
+	if _, err := http.NewWithConfig(server.URL, &tcping.Option{}, config); err == nil {
+		t.Fatal("basic auth and bearer token should not be used together")
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	name, value, err := http.ParseHeader("Accept: application/json")
+	if err != nil || name != "Accept" || value != "application/json" {
+		t.Fatalf("unexpected header %s: %s, %v", name, value, err)
+	}
+	if _, _, err := http.ParseHeader("Accept"); err == nil {
+		t.Fatal("header without value should be invalid")
+	}
+}
+
+func TestPingFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		switch r.URL.Path {
+		case "/":
+			nethttp.Redirect(w, r, "/a", nethttp.StatusMovedPermanently)
+		case "/a":
+			nethttp.Redirect(w, r, "/b", nethttp.StatusSeeOther)
+		case "/b":
+			if r.Method != nethttp.MethodGet {
+				w.WriteHeader(nethttp.StatusMethodNotAllowed)
+			}
+		case "/loop":
+			nethttp.Redirect(w, r, "/loop/next", nethttp.StatusFound)
+		case "/loop/next":
+			nethttp.Redirect(w, r, "/loop", nethttp.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	ping, err := http.NewWithConfig(server.URL+"/", &tcping.Option{}, &http.Config{Method: nethttp.MethodPost, FollowRedirects: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := ping.Ping(context.Background())
+	if stats.Error != nil {
+		t.Fatal(stats.Error)
+	}
+	hops := stats.Extra.(http.Hops)
+	if len(hops) != 3 || stats.Meta["redirects"].String() != "2" || stats.Meta["status"].String() != "200" {
+		t.Fatalf("unexpected hops %s", hops)
+	}
+	for i, status := range []int{301, 303, 200} {
+		if hops[i].Status != status {
+			t.Fatalf("the status of hop %d should be %d, got %d", i+1, status, hops[i].Status)
+		}
+	}
+	if hops[2].URL != server.URL+"/b" {
+		t.Fatalf("unexpected final url %s", hops[2].URL)
+	}
+
+	ping, _ = http.NewWithConfig(server.URL+"/", &tcping.Option{}, &http.Config{FollowRedirects: 1})
+	if stats := ping.Ping(context.Background()); stats.Error == nil || stats.Error.Error() != "stopped after 1 redirects" {
+		t.Fatalf("it should be stopped by the maximum redirects, got %v", stats.Error)
+	}
+
+	ping, _ = http.NewWithConfig(server.URL+"/loop", &tcping.Option{}, &http.Config{FollowRedirects: 10})
+	if stats := ping.Ping(context.Background()); stats.Error == nil || !strings.HasPrefix(stats.Error.Error(), "redirect loop detected") {
+		t.Fatalf("it should be a redirect loop, got %v", stats.Error)
+	}
+
+	ping, _ = http.NewWithConfig(server.URL+"/", &tcping.Option{}, &http.Config{})
+	if stats := ping.Ping(context.Background()); stats.Meta["status"].String() != "301" {
+		t.Fatalf("it should not follow the redirect, got %s", stats.Meta["status"])
+	}
+}
+
+func TestPingHTTPVersion(t *testing.T) {
+	handler := nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+	server := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer server.Close()
+
+	for version, proto := range map[string]string{"1.1": "HTTP/1.1", "2": "HTTP/2.0"} {
+		ping, err := http.NewWithConfig(server.URL, &tcping.Option{}, &http.Config{Version: version})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := ping.Ping(context.Background())
+		if stats.Error != nil {
+			t.Fatal(stats.Error)
+		}
+		if stats.Meta["proto"].String() != proto {
+			t.Fatalf("the protocol of %s should be %s, got %s", version, proto, stats.Meta["proto"])
+		}
+	}
+
+	if _, err := http.NewWithConfig(server.URL, &tcping.Option{}, &http.Config{Version: "3"}); err == nil {
+		t.Fatal("http/3 should require https")
+	}
+	if _, err := http.NewWithConfig(server.URL, &tcping.Option{}, &http.Config{Version: "1.0"}); err == nil {
+		t.Fatal("http version 1.0 should not be supported")
+	}
+}
+
+// protoHandler writes the protocol of the request, or redirects to the query parameter to.
+var protoHandler = nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+	if to := r.URL.Query().Get("to"); to != "" {
+		nethttp.Redirect(w, r, to, nethttp.StatusFound)
+		return
+	}
+	_, _ = w.Write([]byte(r.Proto))
+})
+
+func TestPingHTTPVersionRedirect(t *testing.T) {
+	plain := httptest.NewServer(h2c.NewHandler(protoHandler, &http2.Server{}))
+	defer plain.Close()
+	secure := httptest.NewUnstartedServer(protoHandler)
+	secure.EnableHTTP2 = true
+	secure.StartTLS()
+	defer secure.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(secure.Certificate())
+
+	// h2c to h2 over TLS and back, every hop is requested with the transport of its scheme
+	for _, url := range []string{plain.URL + "/?to=" + secure.URL, secure.URL + "/?to=" + plain.URL} {
+		ping, err := http.NewWithConfig(url, &tcping.Option{}, &http.Config{
+			Version:         "2",
+			FollowRedirects: 1,
+			TLSConfig:       &tls.Config{RootCAs: pool},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := ping.Ping(context.Background())
+		if stats.Error != nil {
+			t.Fatalf("%s: %s", url, stats.Error)
+		}
+		if stats.Meta["proto"].String() != "HTTP/2.0" || stats.Meta["redirects"].String() != "1" {
+			t.Fatalf("%s: the redirect should be requested with HTTP/2.0, got %s", url, stats.FormatMeta())
+		}
+	}
+}
+
+func TestPingHTTP3(t *testing.T) {
+	secure := httptest.NewUnstartedServer(protoHandler)
+	secure.StartTLS()
+	defer secure.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(secure.Certificate())
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http3.Server{Handler: protoHandler, TLSConfig: http3.ConfigureTLSConfig(secure.TLS)}
+	go func() {
+		_ = server.Serve(conn)
+	}()
+	defer server.Close()
+	url := "https://" + conn.LocalAddr().String()
+
+	ping, err := http.NewWithConfig(url, &tcping.Option{}, &http.Config{
+		Version:   "3",
+		Expect:    &http.Expect{Body: regexp.MustCompile(`^HTTP/3\.0$`)},
+		TLSConfig: &tls.Config{RootCAs: pool},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every probe dials a new QUIC connection
+	for i := 0; i < 2; i++ {
+		stats := ping.Ping(context.Background())
+		if stats.Error != nil {
+			t.Fatal(stats.Error)
+		}
+		if stats.Meta["proto"].String() != "HTTP/3.0" || stats.Address != "127.0.0.1" {
+			t.Fatalf("the probe should be HTTP/3.0 to 127.0.0.1, got %s %s", stats.Address, stats.FormatMeta())
+		}
+	}
+
+	ping, _ = http.NewWithConfig(url+"/?to=http://127.0.0.1:1/", &tcping.Option{}, &http.Config{
+		Version:         "3",
+		FollowRedirects: 1,
+		TLSConfig:       &tls.Config{RootCAs: pool},
+	})
+	stats := ping.Ping(context.Background())
+	if stats.Error == nil || !strings.Contains(stats.Error.Error(), "http/3 requires https") {
+		t.Fatalf("the redirect to http should fail, got %v", stats.Error)
+	}
+}
+
+func TestPingKeepAlive(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		if r.URL.Path == "/close" {
+			w.Header().Set("Connection", "close")
+		}
+	}))
+	defer server.Close()
+
+	probe := func(ping *http.Ping) (connection, reconnect string) {
+		stats := ping.Ping(context.Background())
+		if stats.Error != nil {
+			t.Fatal(stats.Error)
+		}
+		if stats.Address != "127.0.0.1" {
+			t.Fatalf("unexpected address %s", stats.Address)
+		}
+		if r, ok := stats.Meta["reconnect"]; ok {
+			reconnect = r.String()
+		}
+		return stats.Meta["connection"].String(), reconnect
+	}
+	expect := func(ping *http.Ping, connection, reconnect string) {
+		c, r := probe(ping)
+		if c != connection || r != reconnect {
+			t.Fatalf("the connection should be %s (%s), got %s (%s)", connection, reconnect, c, r)
+		}
+	}
+
+	ping, err := http.NewWithConfig(server.URL, &tcping.Option{}, &http.Config{KeepAlive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(ping, "new", "")
+	expect(ping, "reused", "")
+	server.CloseClientConnections()
+	// wait until the client reads the close
+	for deadline := time.Now().Add(5 * time.Second); ping.ClosedReason() == ""; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the client should see the close of the connection")
+		}
+	}
+	expect(ping, "new", http.ReasonServerClose)
+	expect(ping, "reused", "")
+
+	ping, _ = http.NewWithConfig(server.URL+"/close", &tcping.Option{}, &http.Config{KeepAlive: true})
+	expect(ping, "new", "")
+	expect(ping, "new", http.ReasonServerClose)
+}
+
+// serveSOCKS runs a SOCKS5 proxy without authentication on ln and relays the connections.
+func serveSOCKS(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			buf := make([]byte, 256)
+			if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+				return
+			}
+			if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte{0x05, 0x00})
+			if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+				return
+			}
+			var host string
+			switch buf[3] {
+			case 0x01:
+				_, _ = io.ReadFull(conn, buf[:net.IPv4len])
+				host = net.IP(buf[:net.IPv4len]).String()
+			case 0x03:
+				_, _ = io.ReadFull(conn, buf[:1])
+				n := int(buf[0])
+				_, _ = io.ReadFull(conn, buf[:n])
+				host = string(buf[:n])
+			default:
+				return
+			}
+			_, _ = io.ReadFull(conn, buf[:2])
+			target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(buf[0])<<8|int(buf[1]))))
+			if err != nil {
+				_, _ = conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+				return
+			}
+			defer target.Close()
+			_, _ = conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+			go func() { _, _ = io.Copy(target, conn) }()
+			_, _ = io.Copy(conn, target)
+		}()
+	}
+}
+
+func TestPingKeepAliveSOCKS(t *testing.T) {
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {}))
+	defer server.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go serveSOCKS(ln)
+
+	proxy, _ := url.Parse("socks5://" + ln.Addr().String())
+	ping, err := http.NewWithConfig(server.URL, &tcping.Option{Proxy: proxy}, &http.Config{KeepAlive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, connection := range []string{"new", "reused"} {
+		stats := ping.Ping(context.Background())
+		if stats.Error != nil {
+			t.Fatal(stats.Error)
+		}
+		if c := stats.Meta["connection"].String(); c != connection {
+			t.Fatalf("probe %d: the connection should be %s, got %s", i, connection, c)
+		}
+		if _, ok := stats.Meta["proxyConnect"]; ok != (connection == "new") {
+			t.Fatalf("probe %d: the proxy meta is only for the new connection, got %v", i, stats.Meta)
+		}
+	}
+}
+
+func TestPingTrace(t *testing.T) {
+	const delay = 60 * time.Millisecond
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		// the server processing
+		time.Sleep(delay)
+		_, _ = w.Write(make([]byte, 1024))
+		w.(nethttp.Flusher).Flush()
+		// the body transfer
+		time.Sleep(delay)
+		_, _ = w.Write(make([]byte, 1024))
+	}))
+	defer server.Close()
+
+	ping, err := http.NewWithConfig(server.URL, &tcping.Option{}, &http.Config{Trace: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := ping.Ping(context.Background())
+	if stats.Error != nil {
+		t.Fatal(stats.Error)
+	}
+	trace := stats.Extra.(*http.Trace)
+
+	timings := []time.Duration{trace.NameLookup, trace.Connect, trace.PreTransfer, trace.StartTransfer, trace.Total}
+	for i := 1; i < len(timings); i++ {
+		if timings[i] < timings[i-1] {
+			t.Fatalf("the timings should be cumulative, got %s", trace)
+		}
+	}
+	if trace.Connect <= 0 || trace.AppConnect != 0 {
+		t.Fatalf("unexpected connect timings, %s", trace)
+	}
+	if trace.StartTransfer < delay || trace.WaitResponseDuration < delay || trace.WaitResponseDuration > trace.StartTransfer {
+		t.Fatalf("the server processing should be at least %s, %s", delay, trace)
+	}
+	if trace.BodyDuration < delay || trace.Total < 2*delay || trace.Total > stats.Duration {
+		t.Fatalf("the body transfer should be at least %s, %s", delay, trace)
+	}
+	if trace.Bytes != 2048 || trace.Throughput <= 0 || trace.Throughput > float64(2048)/delay.Seconds() {
+		t.Fatalf("unexpected throughput %f of %d bytes", trace.Throughput, trace.Bytes)
+	}
+	if s := trace.String(); !strings.Contains(s, "starttransfer="+trace.StartTransfer.String()) || !strings.Contains(s, "body="+trace.BodyDuration.String()) {
+		t.Fatalf("unexpected trace %s", s)
+	}
+}
+
+func TestFormatThroughput(t *testing.T) {
+	for bytesPerSecond, s := range map[float64]string{0: "0B/s", 512: "512B/s", 1536: "1.5KB/s", 3 * 1024 * 1024: "3.0MB/s"} {
+		if got := http.FormatThroughput(bytesPerSecond); got != s {
+			t.Fatalf("%f should be formatted as %s, got %s", bytesPerSecond, s, got)
+		}
+	}
+}
+
+func TestPingMaxBodyBytes(t *testing.T) {
+	body := strings.Repeat("x", 1<<20)
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		nethttp.ServeContent(w, r, "", time.Time{}, strings.NewReader(body))
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		config    http.Config
+		status    string
+		bytes     string
+		truncated bool
+	}{
+		{config: http.Config{}, status: "200", bytes: "1048576"},
+		{config: http.Config{MaxBodyBytes: 1024}, status: "200", bytes: "1024", truncated: true},
+		{config: http.Config{MaxBodyBytes: 1 << 20}, status: "200", bytes: "1048576"},
+		{config: http.Config{Range: "0-1023"}, status: "206", bytes: "1024"},
+		{config: http.Config{Range: "-10"}, status: "206", bytes: "10"},
+		{config: http.Config{Range: "1024-", MaxBodyBytes: 100}, status: "206", bytes: "100", truncated: true},
+	}
+	for _, c := range cases {
+		ping, err := http.NewWithConfig(server.URL, &tcping.Option{}, &c.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stats := ping.Ping(context.Background())
+		if stats.Error != nil {
+			t.Fatal(stats.Error)
+		}
+		if status := stats.Meta["status"].String(); status != c.status {
+			t.Fatalf("%+v: the status should be %s, got %s", c.config, c.status, status)
+		}
+		if bytes := stats.Meta["bytes"].String(); bytes != c.bytes {
+			t.Fatalf("%+v: the bytes should be %s, got %s", c.config, c.bytes, bytes)
+		}
+		if _, truncated := stats.Meta["truncated"]; truncated != c.truncated {
+			t.Fatalf("%+v: the truncated should be %v", c.config, c.truncated)
+		}
+		if _, ok := stats.Meta["throughput"].(http.Throughput); !ok {
+			t.Fatalf("%+v: the throughput is not in the meta", c.config)
+		}
+	}
+
+	for _, r := range []string{"1-0", "-", "a-b", "10", "bytes=0-1"} {
+		if _, err := http.NewWithConfig(server.URL, &tcping.Option{}, &http.Config{Range: r}); err == nil {
+			t.Fatalf("the range %s should be invalid", r)
+		}
+	}
+	if _, err := http.NewWithConfig(server.URL, &tcping.Option{}, &http.Config{MaxBodyBytes: -1}); err == nil {
+		t.Fatal("the negative max body bytes should be invalid")
+	}
+}
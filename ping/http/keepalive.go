@@ -0,0 +1,124 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+// DefaultIdleTimeout is the time an idle connection is kept in keepalive mode.
+const DefaultIdleTimeout = 90 * time.Second
+
+// the reasons of a reconnect in keepalive mode
+const (
+	ReasonServerClose = "server close"
+	ReasonIdleTimeout = "idle timeout"
+	ReasonError       = "error"
+	ReasonClosed      = "closed"
+)
+
+// connTracker records why the last connection was closed in keepalive mode.
+type connTracker struct {
+	mu     sync.Mutex
+	reason string
+	// connected is true after the first connection
+	connected bool
+}
+
+// closed records the reason of the connection close, the first reason wins.
+func (t *connTracker) closed(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.reason == "" {
+		t.reason = reason
+	}
+}
+
+// reconnect returns the reason of a new connection, it is empty for the first connection.
+func (t *connTracker) reconnect() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	reason := t.reason
+	t.reason = ""
+	if !t.connected {
+		t.connected = true
+		return ""
+	}
+	if reason == "" {
+		reason = ReasonClosed
+	}
+	return reason
+}
+
+// trackedConn reports the close of the connection to the tracker.
+type trackedConn struct {
+	net.Conn
+	tracker *connTracker
+
+	mu       sync.Mutex
+	lastUsed time.Time
+}
+
+func newTrackedConn(conn net.Conn, tracker *connTracker) *trackedConn {
+	return &trackedConn{Conn: conn, tracker: tracker, lastUsed: time.Now()}
+}
+
+func (c *trackedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.used()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			c.tracker.closed(ReasonServerClose)
+		} else if !errors.Is(err, net.ErrClosed) {
+			c.tracker.closed(ReasonError)
+		}
+	}
+	return n, err
+}
+
+func (c *trackedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.used()
+	return n, err
+}
+
+func (c *trackedConn) Close() error {
+	c.mu.Lock()
+	idle := time.Since(c.lastUsed)
+	c.mu.Unlock()
+	if idle >= DefaultIdleTimeout {
+		c.tracker.closed(ReasonIdleTimeout)
+	}
+	return c.Conn.Close()
+}
+
+func (c *trackedConn) used() {
+	c.mu.Lock()
+	c.lastUsed = time.Now()
+	c.mu.Unlock()
+}
+
+// trackQUIC reports the close of the QUIC connection to the tracker.
+func trackQUIC(conn quic.Connection, tracker *connTracker) {
+	<-conn.Context().Done()
+	var (
+		idleErr *quic.IdleTimeoutError
+		appErr  *quic.ApplicationError
+	)
+	err := context.Cause(conn.Context())
+	switch {
+	case errors.As(err, &idleErr):
+		tracker.closed(ReasonIdleTimeout)
+	case errors.As(err, &appErr) && appErr.Remote:
+		tracker.closed(ReasonServerClose)
+	case errors.As(err, &appErr):
+		// closed by the client
+	default:
+		tracker.closed(ReasonError)
+	}
+}
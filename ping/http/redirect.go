@@ -0,0 +1,44 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRedirects is the maximum redirects to follow when it is not given.
+const DefaultMaxRedirects = 10
+
+var _ fmt.Stringer = (Hops)(nil)
+
+// Hop is a request of the redirect chain.
+type Hop struct {
+	URL      string        `json:"url"`
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Trace    *Trace        `json:"trace"`
+}
+
+// Hops is the redirect chain of a probe, the last one is the final response.
+type Hops []*Hop
+
+func (hops Hops) String() string {
+	builder := strings.Builder{}
+	for i, hop := range hops {
+		if i > 0 {
+			builder.WriteString("\n ")
+		}
+		status := "-"
+		if hop.Status != 0 {
+			status = strconv.Itoa(hop.Status)
+		}
+		builder.WriteString(fmt.Sprintf("%d. %s %s time=%s %s", i+1, status, hop.URL, hop.Duration, hop.Trace))
+	}
+	return builder.String()
+}
+
+func (hops Hops) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]*Hop(hops))
+}
@@ -3,87 +3,242 @@ package http
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http/httptrace"
 	"strings"
+	"sync"
 	"time"
 )
 
 var _ fmt.Stringer = (*Trace)(nil)
 
+// Trace is the timing of a request. The phase durations are the time of every phase,
+// the curl-like timings are cumulative from the start of the request, see curl --write-out.
 type Trace struct {
-	DNSDuration time.Duration `json:"dns_duration"`
-
-	connectStart    time.Time
+	DNSDuration     time.Duration `json:"dns_duration"`
 	ConnectDuration time.Duration `json:"connect_duration"`
-
-	tlsStart    time.Time
-	tls         bool
-	TLSDuration time.Duration `json:"tls_duration"`
-
+	TLSDuration     time.Duration `json:"tls_duration"`
+	// WroteRequestDuration is the time to write the request on the connection
 	WroteRequestDuration time.Duration `json:"wrote_request_duration"`
-
+	// WaitResponseDuration is the time from the request written to the first response byte
 	WaitResponseDuration time.Duration `json:"wait_response_duration"`
-
+	// BodyDuration is the time to read the body after the response header
 	BodyDuration time.Duration `json:"body_duration"`
 
+	NameLookup    time.Duration `json:"namelookup"`
+	Connect       time.Duration `json:"connect"`
+	AppConnect    time.Duration `json:"appconnect"`
+	PreTransfer   time.Duration `json:"pretransfer"`
+	StartTransfer time.Duration `json:"starttransfer"`
+	Total         time.Duration `json:"total"`
+
+	// Bytes is the size of the body read
+	Bytes int64 `json:"bytes"`
+	// Throughput is the bytes per second of the body
+	Throughput float64 `json:"throughput"`
+
+	// mu guards the events below, the hooks are called from the goroutines of the transport, e.g. HTTP/2
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	gotResponse  time.Time
+
+	tls      bool
 	tlsState tls.ConnectionState
 
 	address string
 }
 
 func (t *Trace) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	builder := strings.Builder{}
 
-	builder.WriteString(fmt.Sprintf("connect=%s", t.ConnectDuration))
-
+	builder.WriteString(fmt.Sprintf("namelookup=%s", t.NameLookup))
+	builder.WriteString(fmt.Sprintf(" connect=%s", t.Connect))
 	if t.tls {
-		builder.WriteString(" ")
-		builder.WriteString(fmt.Sprintf("tls=%s", t.TLSDuration))
+		builder.WriteString(fmt.Sprintf(" appconnect=%s", t.AppConnect))
+	}
+	builder.WriteString(fmt.Sprintf(" pretransfer=%s", t.PreTransfer))
+	builder.WriteString(fmt.Sprintf(" starttransfer=%s", t.StartTransfer))
+	builder.WriteString(fmt.Sprintf(" total=%s", t.Total))
+	builder.WriteString(fmt.Sprintf(" body=%s", t.BodyDuration))
+	builder.WriteString(fmt.Sprintf(" throughput=%s", FormatThroughput(t.Throughput)))
+	if t.tls && t.tlsState.Version != 0 {
+		builder.WriteString(fmt.Sprintf(" tls=%s cipher=%s", tls.VersionName(t.tlsState.Version), tls.CipherSuiteName(t.tlsState.CipherSuite)))
 	}
 
-	builder.WriteString(" ")
-	builder.WriteString(fmt.Sprintf("request=%s", t.WroteRequestDuration))
+	return builder.String()
+}
+
+func (t *Trace) MarshalJSON() ([]byte, error) {
+	type trace Trace
+	return json.Marshal((*trace)(t))
+}
 
-	builder.WriteString(" ")
-	builder.WriteString(fmt.Sprintf("wait_response=%s", t.WaitResponseDuration))
+// Throughput is the bytes per second of a meta value.
+type Throughput float64
 
-	builder.WriteString(" ")
-	builder.WriteString(fmt.Sprintf("response_body=%s", t.WaitResponseDuration))
+func (t Throughput) String() string {
+	return FormatThroughput(float64(t))
+}
 
-	return builder.String()
+func (t Throughput) MarshalJSON() ([]byte, error) {
+	return json.Marshal(float64(t))
+}
+
+// FormatThroughput formats the bytes per second, e.g. 1.5MB/s.
+func FormatThroughput(bytesPerSecond float64) string {
+	const unit = 1024
+	if bytesPerSecond < unit {
+		return fmt.Sprintf("%.0fB/s", bytesPerSecond)
+	}
+	div, exp := float64(unit), 0
+	for n := bytesPerSecond / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB/s", bytesPerSecond/div, "KMGT"[exp])
 }
 
 func (t *Trace) WithTrace(ctx context.Context) context.Context {
-	start := time.Now()
+	t.start = time.Now()
+	// record the time of the event and the state of it under the lock
+	record := func(at *time.Time, state func()) {
+		now := time.Now()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		*at = now
+		if state != nil {
+			state()
+		}
+	}
 	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
 		DNSStart: func(info httptrace.DNSStartInfo) {
-			start = time.Now()
+			record(&t.dnsStart, nil)
 		},
 		DNSDone: func(info httptrace.DNSDoneInfo) {
-			t.DNSDuration = time.Since(start)
+			record(&t.dnsDone, nil)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			record(&t.gotConn, func() {
+				if t.address == "" && info.Conn != nil {
+					// the reused connection is not connected
+					t.address, _, _ = net.SplitHostPort(info.Conn.RemoteAddr().String())
+				}
+			})
 		},
 		ConnectStart: func(network, addr string) {
-			t.connectStart = time.Now()
-			t.address, _, _ = net.SplitHostPort(addr)
+			record(&t.connectStart, func() {
+				t.address, _, _ = net.SplitHostPort(addr)
+			})
 		},
 		ConnectDone: func(network, addr string, err error) {
-			t.ConnectDuration = time.Since(t.connectStart)
+			record(&t.connectDone, nil)
 		},
 		TLSHandshakeStart: func() {
-			t.tlsStart = time.Now()
-			t.tls = true
+			record(&t.tlsStart, func() {
+				t.tls = true
+			})
 		},
 		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
-			t.TLSDuration = time.Since(t.tlsStart)
-			t.tlsState = state
+			record(&t.tlsDone, func() {
+				t.tlsState = state
+			})
 		},
 		WroteRequest: func(info httptrace.WroteRequestInfo) {
-			t.WroteRequestDuration = time.Since(start) - t.TLSDuration - t.ConnectDuration - t.DNSDuration
+			record(&t.wroteRequest, nil)
 		},
 		GotFirstResponseByte: func() {
-			t.WaitResponseDuration = time.Since(start) - t.WaitResponseDuration - t.TLSDuration - t.ConnectDuration - t.DNSDuration
+			record(&t.firstByte, nil)
 		},
 	})
 }
+
+// lookup returns the duration of the DNS lookup.
+func (t *Trace) lookup() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.dnsStart.IsZero() || t.dnsDone.IsZero() {
+		return 0
+	}
+	return t.dnsDone.Sub(t.dnsStart)
+}
+
+// remoteAddress returns the IP of the connection.
+func (t *Trace) remoteAddress() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.address
+}
+
+// connected reports whether a new connection is dialed.
+func (t *Trace) connected() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return !t.connectStart.IsZero()
+}
+
+// responded records the time the response header is received.
+func (t *Trace) responded() {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.gotResponse = now
+}
+
+// done computes the timings after the body of n bytes is read.
+func (t *Trace) done(n int64) {
+	end := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	since := func(at time.Time, or time.Duration) time.Duration {
+		if at.IsZero() {
+			return or
+		}
+		return at.Sub(t.start)
+	}
+	between := func(start, end time.Time) time.Duration {
+		if start.IsZero() || end.IsZero() {
+			return 0
+		}
+		return end.Sub(start)
+	}
+
+	t.DNSDuration = between(t.dnsStart, t.dnsDone)
+	t.ConnectDuration = between(t.connectStart, t.connectDone)
+	t.TLSDuration = between(t.tlsStart, t.tlsDone)
+
+	t.NameLookup = since(t.dnsDone, 0)
+	t.Connect = since(t.connectDone, t.NameLookup)
+	if !t.tlsDone.IsZero() {
+		t.AppConnect = since(t.tlsDone, 0)
+	}
+	// HTTP/3 dials without GotConn
+	connected := t.Connect
+	if t.AppConnect > connected {
+		connected = t.AppConnect
+	}
+	t.PreTransfer = since(t.gotConn, connected)
+	t.StartTransfer = since(t.firstByte, since(t.gotResponse, t.PreTransfer))
+	t.Total = end.Sub(t.start)
+
+	t.WroteRequestDuration = between(t.gotConn, t.wroteRequest)
+	t.WaitResponseDuration = between(t.wroteRequest, t.firstByte)
+	t.BodyDuration = between(t.gotResponse, end)
+
+	t.Bytes = n
+	if t.BodyDuration > 0 {
+		t.Throughput = float64(n) / t.BodyDuration.Seconds()
+	}
+}
@@ -6,12 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
-	"io"
-	"math"
 	"net"
 	"net/url"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -40,6 +39,12 @@ func (protocol Protocol) String() string {
 		return "http"
 	case HTTPS:
 		return "https"
+	case UDP:
+		return "udp"
+	case DNS:
+		return "dns"
+	case TLS:
+		return "tls"
 	}
 	return "unknown"
 }
@@ -51,6 +56,12 @@ const (
 	HTTP
 	// HTTPS is https protocol
 	HTTPS
+	// UDP is udp protocol
+	UDP
+	// DNS is dns query protocol
+	DNS
+	// TLS is tls handshake protocol
+	TLS
 )
 
 // NewProtocol convert protocol string to Protocol
@@ -62,6 +73,12 @@ func NewProtocol(protocol string) (Protocol, error) {
 		return HTTP, nil
 	case HTTPS.String():
 		return HTTPS, nil
+	case UDP.String():
+		return UDP, nil
+	case DNS.String():
+		return DNS, nil
+	case TLS.String():
+		return TLS, nil
 	}
 	return 0, fmt.Errorf("protocol %s not support", protocol)
 }
@@ -71,6 +88,37 @@ type Option struct {
 	Resolver *net.Resolver
 	Proxy    *url.URL
 	UA       string
+	// CertWarnDays checks the expiry of the peer certificates when it is positive, see CheckCertificates
+	CertWarnDays int
+	// IPVersion restricts the address family to 4 or 6, 0 is any
+	IPVersion int
+	// Address is the IP to connect to instead of resolving the host
+	Address string
+	// SourceIP is the local address of the probes, nil is any
+	SourceIP net.IP
+	// Interface is the network interface the probes are bound to, only supported on linux
+	Interface string
+}
+
+// Network returns the network restricted to the IP version, e.g. tcp4.
+func (op *Option) Network(network string) string {
+	switch op.IPVersion {
+	case 4, 6:
+		return network + strconv.Itoa(op.IPVersion)
+	}
+	return network
+}
+
+// DialAddress returns the address to connect to, the host of addr is replaced by Address.
+func (op *Option) DialAddress(addr string) string {
+	if op.Address == "" {
+		return addr
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return net.JoinHostPort(op.Address, port)
 }
 
 // Target is a ping
@@ -87,17 +135,19 @@ type Target struct {
 }
 
 func (target Target) String() string {
-	return fmt.Sprintf("%s://%s:%d", target.Protocol, target.Host, target.Port)
+	return fmt.Sprintf("%s://%s", target.Protocol, net.JoinHostPort(target.Host, strconv.Itoa(target.Port)))
 }
 
 type Stats struct {
 	Connected   bool                    `json:"connected"`
 	Error       error                   `json:"error"`
 	Duration    time.Duration           `json:"duration"`
-	DNSDuration time.Duration           `json:"DNSDuration"`
+	DNSDuration time.Duration           `json:"dns_duration"`
 	Address     string                  `json:"address"`
 	Meta        map[string]fmt.Stringer `json:"meta"`
 	Extra       fmt.Stringer            `json:"extra"`
+	// Warning is set when the probe is successful but needs attention
+	Warning string `json:"warning"`
 }
 
 func (s *Stats) FormatMeta() string {
@@ -118,18 +168,48 @@ func (s *Stats) FormatMeta() string {
 	return builder.String()
 }
 
+// Int is an integer meta value.
+type Int int
+
+func (i Int) String() string {
+	return strconv.Itoa(int(i))
+}
+
+func (i Int) MarshalJSON() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// String is a string meta value.
+type String string
+
+func (s String) String() string {
+	return string(s)
+}
+
 type Ping interface {
 	Ping(ctx context.Context) *Stats
 }
 
-func NewPinger(out io.Writer, url *url.URL, ping Ping, interval time.Duration, counter int) *Pinger {
+// Reporter receives the result of every probe and the final summary of a Pinger.
+type Reporter interface {
+	OnProbe(stats *Stats)
+	OnSummary(result Result)
+}
+
+// NewPinger create a Pinger, the results are reported to reporter,
+// it will write text to os.Stdout if reporter is nil.
+func NewPinger(reporter Reporter, url *url.URL, ping Ping, interval time.Duration, counter int) *Pinger {
+	if reporter == nil {
+		reporter = NewTextReporter(os.Stdout, url)
+	}
 	return &Pinger{
-		stopC:    make(chan struct{}),
-		counter:  counter,
-		interval: interval,
-		out:      out,
-		url:      url,
-		ping:     ping,
+		stopC:      make(chan struct{}),
+		counter:    counter,
+		interval:   interval,
+		reporter:   reporter,
+		url:        url,
+		ping:       ping,
+		statistics: NewStatistics(DefaultSamples),
 	}
 }
 
@@ -139,18 +219,39 @@ type Pinger struct {
 	stopOnce sync.Once
 	stopC    chan struct{}
 
-	out io.Writer
+	reporter Reporter
 
 	url *url.URL
+	// ip is the address the pinger connects to, see SetIP
+	ip string
 
 	interval time.Duration
 	counter  int
 
-	minDuration   time.Duration
-	maxDuration   time.Duration
-	totalDuration time.Duration
-	total         int
-	failedTotal   int
+	statistics   *Statistics
+	total        int
+	failedTotal  int
+	warningTotal int
+
+	// stop after the consecutive successful probes when it is positive
+	successes   int
+	consecutive int
+}
+
+// StopAfterSuccesses stops the pinger after n consecutive successful probes,
+// it must be called before Ping.
+func (p *Pinger) StopAfterSuccesses(n int) {
+	p.successes = n
+}
+
+// SetIP records the IP address the pinger connects to, it is reported as Result.Target.IP.
+func (p *Pinger) SetIP(ip string) {
+	p.ip = ip
+}
+
+// Reached reports whether the consecutive successful probes of StopAfterSuccesses are reached.
+func (p *Pinger) Reached() bool {
+	return p.successes > 0 && p.consecutive >= p.successes
 }
 
 func (p *Pinger) Stop() {
@@ -182,15 +283,22 @@ func (p *Pinger) Ping() {
 	defer timer.Stop()
 
 	stop := false
-	p.minDuration = time.Duration(math.MaxInt64)
 	for !stop {
 		select {
 		case <-timer.C:
 			stats := p.ping.Ping(ctx)
+			if stats.Error != nil && (ctx.Err() != nil || errors.Is(stats.Error, context.Canceled)) {
+				// cancelled by Stop, the unfinished probe is neither sent nor failed
+				stop = true
+				continue
+			}
 			p.logStats(stats)
 			if p.total++; p.counter > 0 && p.total > p.counter-1 {
 				stop = true
 			}
+			if p.Reached() {
+				stop = true
+			}
 			timer.Reset(interval)
 		case <-p.Done():
 			stop = true
@@ -198,25 +306,38 @@ func (p *Pinger) Ping() {
 	}
 }
 
-func (p *Pinger) Summarize() {
-
-	const tpl = `
-Ping statistics %s
-	%d probes sent.
-	%d successful, %d failed.
-Approximate trip times:
-	Minimum = %s, Maximum = %s, Average = %s`
+// Result returns the statistics of the probes sent so far.
+func (p *Pinger) Result() Result {
+	percentiles := p.statistics.Percentiles(50, 90, 95, 99)
+	return Result{
+		Counter:        p.total,
+		SuccessCounter: p.total - p.failedTotal,
+		WarningCounter: p.warningTotal,
+		Target:         newTarget(p.url, p.ip),
+		MinDuration:    p.statistics.Min(),
+		MaxDuration:    p.statistics.Max(),
+		TotalDuration:  p.statistics.Total(),
+		P50:            percentiles[0],
+		P90:            percentiles[1],
+		P95:            percentiles[2],
+		P99:            percentiles[3],
+		StdDev:         p.statistics.StdDev(),
+		Jitter:         p.statistics.Jitter(),
+	}
+}
 
-	_, _ = fmt.Fprintf(p.out, tpl, p.url.String(), p.total, p.total-p.failedTotal, p.failedTotal, p.minDuration, p.maxDuration, p.totalDuration/time.Duration(p.total))
+func (p *Pinger) Summarize() {
+	p.reporter.OnSummary(p.Result())
 }
 
-func (p *Pinger) formatError(err error) string {
+// FormatError returns a short description of the probe error, e.g. timeout.
+func FormatError(err error) string {
 	switch err := err.(type) {
 	case *url.Error:
 		if err.Timeout() {
 			return "timeout"
 		}
-		return p.formatError(err.Err)
+		return FormatError(err.Err)
 	case net.Error:
 		if err.Timeout() {
 			return "timeout"
@@ -236,48 +357,37 @@ func (p *Pinger) formatError(err error) string {
 }
 
 func (p *Pinger) logStats(stats *Stats) {
-	if stats.Duration < p.minDuration {
-		p.minDuration = stats.Duration
-	}
-	if stats.Duration > p.maxDuration {
-		p.maxDuration = stats.Duration
+	if stats.Warning != "" {
+		p.warningTotal++
 	}
-	p.totalDuration += stats.Duration
-	if stats.Error != nil {
-		p.failedTotal++
-		if errors.Is(stats.Error, context.Canceled) {
-			// ignore cancel
-			return
-		}
-	}
-	status := "Failed"
-	if stats.Connected {
-		status = "connected"
-	}
-
-	if stats.Error != nil {
-		_, _ = fmt.Fprintf(p.out, "Ping %s(%s) %s(%s) - time=%s dns=%s", p.url.String(), stats.Address, status, p.formatError(stats.Error), stats.Duration, stats.DNSDuration)
+	if stats.Error == nil {
+		p.consecutive++
+		p.statistics.Add(stats.Duration)
 	} else {
-		_, _ = fmt.Fprintf(p.out, "Ping %s(%s) %s - time=%s dns=%s", p.url.String(), stats.Address, status, stats.Duration, stats.DNSDuration)
-	}
-	if len(stats.Meta) > 0 {
-		_, _ = fmt.Fprintf(p.out, " %s", stats.FormatMeta())
-	}
-	_, _ = fmt.Fprint(p.out, "\n")
-	if stats.Extra != nil {
-		_, _ = fmt.Fprintf(p.out, " %s\n", strings.TrimSpace(stats.Extra.String()))
+		p.consecutive = 0
+		p.failedTotal++
 	}
+	p.reporter.OnProbe(stats)
 }
 
 // Result ...
 type Result struct {
 	Counter        int
 	SuccessCounter int
+	WarningCounter int
 	Target         *Target
 
+	// the durations are computed over the successful probes
 	MinDuration   time.Duration
 	MaxDuration   time.Duration
 	TotalDuration time.Duration
+
+	P50    time.Duration
+	P90    time.Duration
+	P95    time.Duration
+	P99    time.Duration
+	StdDev time.Duration
+	Jitter time.Duration
 }
 
 // Avg return the average time of ping
@@ -295,13 +405,26 @@ func (result Result) Failed() int {
 
 func (result Result) String() string {
 	const resultTpl = `
-Ping statistics {{.Target}}
+Ping statistics {{.Target}}{{if .Target.IP}} ({{.Target.IP}}){{end}}
 	{{.Counter}} probes sent.
-	{{.SuccessCounter}} successful, {{.Failed}} failed.
+	{{.SuccessCounter}} successful, {{.Failed}} failed{{if .WarningCounter}}, {{.WarningCounter}} warnings{{end}}.
 Approximate trip times:
-	Minimum = {{.MinDuration}}, Maximum = {{.MaxDuration}}, Average = {{.Avg}}`
+	Minimum = {{.MinDuration}}, Maximum = {{.MaxDuration}}, Average = {{.Avg}}
+	P50 = {{.P50}}, P90 = {{.P90}}, P95 = {{.P95}}, P99 = {{.P99}}
+	StdDev = {{.StdDev}}, Jitter = {{.Jitter}}`
 	t := template.Must(template.New("result").Parse(resultTpl))
 	res := bytes.NewBufferString("")
 	_ = t.Execute(res, result)
 	return res.String()
 }
+
+func newTarget(u *url.URL, ip string) *Target {
+	protocol, _ := NewProtocol(u.Scheme)
+	port, _ := strconv.Atoi(u.Port())
+	return &Target{
+		Protocol: protocol,
+		Host:     u.Hostname(),
+		IP:       ip,
+		Port:     port,
+	}
+}
@@ -3,9 +3,12 @@ package ping_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	tcping "github.com/cloverstd/tcping/ping"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
@@ -25,7 +28,7 @@ func (s String) String() string {
 func TestPinger(t *testing.T) {
 	u, _ := url.Parse("https://hui.lu")
 	var buf bytes.Buffer
-	pinger := tcping.NewPinger(&buf, u, PingHandler(func(ctx context.Context) *tcping.Stats {
+	pinger := tcping.NewPinger(tcping.NewTextReporter(&buf, u), u, PingHandler(func(ctx context.Context) *tcping.Stats {
 		return &tcping.Stats{
 			Address:     "127.0.0.1:443",
 			Connected:   true,
@@ -42,3 +45,201 @@ func TestPinger(t *testing.T) {
 	pinger.Summarize()
 	fmt.Println(buf.String())
 }
+
+func TestPinger_TextSummary(t *testing.T) {
+	u, _ := url.Parse("tcp://example.com:80")
+	var buf bytes.Buffer
+	pinger := tcping.NewPinger(tcping.NewTextReporter(&buf, u), u, PingHandler(func(ctx context.Context) *tcping.Stats {
+		return &tcping.Stats{Connected: true, Duration: time.Millisecond}
+	}), time.Millisecond, 1)
+	pinger.SetIP("93.184.216.34")
+	pinger.Ping()
+	pinger.Summarize()
+
+	if summary := pinger.Result().String(); !strings.HasSuffix(buf.String(), summary) {
+		t.Fatalf("the summary should be the result, got %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Ping statistics tcp://example.com:80 (93.184.216.34)") {
+		t.Fatalf("the summary should have the IP, got %s", buf.String())
+	}
+}
+
+func TestPinger_NDJSON(t *testing.T) {
+	u, _ := url.Parse("tcp://127.0.0.1:80")
+	var buf bytes.Buffer
+	pinger := tcping.NewPinger(tcping.NewReporter(tcping.FormatNDJSON, &buf, u), u, PingHandler(func(ctx context.Context) *tcping.Stats {
+		return &tcping.Stats{
+			Address:     "127.0.0.1:80",
+			Error:       errors.New("connection refused"),
+			Duration:    time.Millisecond,
+			DNSDuration: 3 * time.Millisecond,
+			Meta: map[string]fmt.Stringer{
+				"status":  String("200"),
+				"connect": 2 * time.Millisecond,
+			},
+		}
+	}), time.Millisecond, 2)
+	pinger.Ping()
+	pinger.Summarize()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("it should output 3 lines, got %d", len(lines))
+	}
+	var probe map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &probe); err != nil {
+		t.Fatal(err)
+	}
+	if probe["type"] != "probe" || probe["error"] != "connection refused" {
+		t.Fatalf("unexpected probe %s", lines[0])
+	}
+	if meta, ok := probe["meta"].(map[string]interface{}); !ok || meta["status"] != "200" || meta["connect"] != float64(2e6) {
+		t.Fatalf("unexpected meta %s", lines[0])
+	}
+	if probe["duration"] != float64(1e6) || probe["dns_duration"] != float64(3e6) {
+		t.Fatalf("the durations should be in nanoseconds, got %s", lines[0])
+	}
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[2]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary["type"] != "summary" || summary["failed"] != float64(2) {
+		t.Fatalf("unexpected summary %s", lines[2])
+	}
+}
+
+type recorder struct {
+	probes []*tcping.Stats
+	result *tcping.Result
+}
+
+func (r *recorder) OnProbe(stats *tcping.Stats) {
+	r.probes = append(r.probes, stats)
+}
+
+func (r *recorder) OnSummary(result tcping.Result) {
+	r.result = &result
+}
+
+func TestPinger_Reporter(t *testing.T) {
+	u, _ := url.Parse("tcp://127.0.0.1:80")
+	var r recorder
+	n := 0
+	pinger := tcping.NewPinger(&r, u, PingHandler(func(ctx context.Context) *tcping.Stats {
+		n++
+		return &tcping.Stats{
+			Connected: n%2 == 0,
+			Duration:  time.Duration(n) * time.Millisecond,
+		}
+	}), time.Millisecond, 4)
+	pinger.Ping()
+	pinger.Summarize()
+
+	if len(r.probes) != 4 {
+		t.Fatalf("it should report 4 probes, got %d", len(r.probes))
+	}
+	if r.result == nil {
+		t.Fatal("it should report summary")
+	}
+	if r.result.Counter != 4 || r.result.MinDuration != time.Millisecond || r.result.MaxDuration != 4*time.Millisecond {
+		t.Fatalf("unexpected result %+v", r.result)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	var pingers []*tcping.Pinger
+	var recorders []*recorder
+	for _, addr := range []string{"tcp://127.0.0.1:80", "tcp://127.0.0.1:443"} {
+		u, _ := url.Parse(addr)
+		r := &recorder{}
+		recorders = append(recorders, r)
+		pingers = append(pingers, tcping.NewPinger(r, u, PingHandler(func(ctx context.Context) *tcping.Stats {
+			return &tcping.Stats{Connected: true, Duration: time.Millisecond}
+		}), time.Millisecond, 3))
+	}
+	group := tcping.NewGroup(pingers...)
+	group.Ping()
+	select {
+	case <-group.Done():
+	default:
+		t.Fatal("group should be done")
+	}
+
+	for _, r := range recorders {
+		if len(r.probes) != 3 {
+			t.Fatalf("it should report 3 probes, got %d", len(r.probes))
+		}
+	}
+	var buf bytes.Buffer
+	tcping.WriteSummaryTable(&buf, group.Results())
+	if !strings.Contains(buf.String(), "tcp://127.0.0.1:443") {
+		t.Fatalf("unexpected table %s", buf.String())
+	}
+}
+
+func TestGroup_Wait(t *testing.T) {
+	u, _ := url.Parse("tcp://127.0.0.1:80")
+	started := make(chan struct{}, 1)
+	pinger := tcping.NewPinger(&recorder{}, u, PingHandler(func(ctx context.Context) *tcping.Stats {
+		started <- struct{}{}
+		// the probe in flight finishes after the stop
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return &tcping.Stats{Connected: true, Duration: time.Millisecond}
+	}), time.Millisecond, 0)
+	pinger.StopAfterSuccesses(2)
+	group := tcping.NewGroup(pinger)
+	go group.Ping()
+	<-started
+	group.Stop()
+	group.Wait()
+
+	if results := group.Results(); results[0].Counter != 1 || results[0].SuccessCounter != 1 {
+		t.Fatalf("the probe in flight should be counted, got %+v", results[0])
+	}
+}
+
+func TestPinger_Cancelled(t *testing.T) {
+	u, _ := url.Parse("tcp://127.0.0.1:80")
+	var r recorder
+	n := 0
+	var pinger *tcping.Pinger
+	pinger = tcping.NewPinger(&r, u, PingHandler(func(ctx context.Context) *tcping.Stats {
+		if n++; n == 1 {
+			return &tcping.Stats{Connected: true, Duration: time.Millisecond}
+		}
+		// interrupted during the second probe
+		pinger.Stop()
+		<-ctx.Done()
+		return &tcping.Stats{Error: ctx.Err()}
+	}), time.Millisecond, 0)
+	pinger.Ping()
+
+	result := pinger.Result()
+	if result.Counter != 1 || result.Failed() != 0 || len(r.probes) != 1 {
+		t.Fatalf("the cancelled probe should not be counted, got %+v", result)
+	}
+}
+
+func TestPinger_StopAfterSuccesses(t *testing.T) {
+	u, _ := url.Parse("tcp://127.0.0.1:80")
+	var r recorder
+	n := 0
+	pinger := tcping.NewPinger(&r, u, PingHandler(func(ctx context.Context) *tcping.Stats {
+		n++
+		// fail, success, fail, success, success
+		if n == 1 || n == 3 {
+			return &tcping.Stats{Error: errors.New("connection refused")}
+		}
+		return &tcping.Stats{Connected: true, Duration: time.Millisecond}
+	}), time.Millisecond, 0)
+	pinger.StopAfterSuccesses(2)
+	pinger.Ping()
+
+	if !pinger.Reached() {
+		t.Fatal("it should reach 2 consecutive successes")
+	}
+	if len(r.probes) != 5 {
+		t.Fatalf("it should stop after 5 probes, got %d", len(r.probes))
+	}
+}
@@ -0,0 +1,288 @@
+package ping
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DefaultSOCKSPort is the port of a socks5 proxy without port.
+const DefaultSOCKSPort = "1080"
+
+// ProxyStats is the timing of a connection through a proxy.
+type ProxyStats struct {
+	// Connect is the time to connect to the proxy
+	Connect time.Duration
+	// Handshake is the time to negotiate the method and authenticate with a socks5 proxy,
+	// or the TLS handshake with an https proxy
+	Handshake time.Duration
+	// Tunnel is the time of the proxy to connect to the target
+	Tunnel time.Duration
+	// Status is the status code of the CONNECT response of an HTTP proxy
+	Status int
+}
+
+// SetMeta adds the timing of the proxy to meta.
+func (s *ProxyStats) SetMeta(meta map[string]fmt.Stringer) {
+	meta["proxyConnect"] = s.Connect
+	if s.Handshake > 0 {
+		meta["proxyHandshake"] = s.Handshake
+	}
+	meta["proxyTunnel"] = s.Tunnel
+	if s.Status != 0 {
+		meta["proxyStatus"] = Int(s.Status)
+	}
+}
+
+// IsSOCKS reports whether u is a socks5 or socks5h proxy.
+func IsSOCKS(u *url.URL) bool {
+	return u != nil && (u.Scheme == "socks5" || u.Scheme == "socks5h")
+}
+
+// DialProxy connects to addr through the proxy of op, or directly when op has no proxy,
+// the ProxyStats is nil without proxy. An http or https proxy opens the tunnel with CONNECT.
+func DialProxy(ctx context.Context, dialer *net.Dialer, op *Option, addr string) (net.Conn, *ProxyStats, error) {
+	if op.Proxy == nil {
+		conn, err := dialer.DialContext(ctx, op.Network("tcp"), op.DialAddress(addr))
+		return conn, nil, err
+	}
+
+	var proxyPort string
+	switch op.Proxy.Scheme {
+	case "socks5", "socks5h":
+		proxyPort = DefaultSOCKSPort
+	case "http":
+		proxyPort = "80"
+	case "https":
+		proxyPort = "443"
+	default:
+		return nil, nil, fmt.Errorf("proxy scheme %s not support", op.Proxy.Scheme)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if op.Address != "" {
+		host = op.Address
+	} else if op.Proxy.Scheme == "socks5" {
+		// socks5 resolves the host locally, the others leave it to the proxy
+		addrs, err := Resolve(ctx, op.Resolver, host, op.IPVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+		host = addrs[0]
+	}
+
+	proxyAddr := op.Proxy.Host
+	if op.Proxy.Port() == "" {
+		proxyAddr = net.JoinHostPort(op.Proxy.Hostname(), proxyPort)
+	}
+	var stats ProxyStats
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	stats.Connect = time.Since(start)
+	if err != nil {
+		return nil, &stats, fmt.Errorf("connect to proxy failed, %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if IsSOCKS(op.Proxy) {
+		err = socksConnect(conn, op.Proxy.User, host, port, &stats)
+	} else {
+		conn, err = httpConnect(ctx, conn, op.Proxy, net.JoinHostPort(host, port), &stats)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, &stats, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return conn, &stats, nil
+}
+
+// httpConnect opens a tunnel to addr with CONNECT on conn to an http or https proxy.
+func httpConnect(ctx context.Context, conn net.Conn, proxy *url.URL, addr string, stats *ProxyStats) (net.Conn, error) {
+	if proxy.Scheme == "https" {
+		start := time.Now()
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxy.Hostname()})
+		err := tlsConn.HandshakeContext(ctx)
+		stats.Handshake = time.Since(start)
+		if err != nil {
+			return conn, fmt.Errorf("proxy tls handshake failed, %w", err)
+		}
+		conn = tlsConn
+	}
+
+	start := time.Now()
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		return conn, fmt.Errorf("proxy CONNECT failed, %w", err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	stats.Tunnel = time.Since(start)
+	if err != nil {
+		return conn, fmt.Errorf("proxy CONNECT failed, %w", err)
+	}
+	resp.Body.Close()
+	stats.Status = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		return conn, fmt.Errorf("proxy CONNECT failed, %s", resp.Status)
+	}
+	if br.Buffered() > 0 {
+		// the target has sent data with the response
+		return &bufferedConn{Conn: conn, reader: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn is a net.Conn which reads the buffered data first.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+const (
+	socksVersion      = 0x05
+	socksNoAuth       = 0x00
+	socksPassword     = 0x02
+	socksNoAcceptable = 0xff
+	socksConnectCmd   = 0x01
+	socksIPv4         = 0x01
+	socksDomain       = 0x03
+	socksIPv6         = 0x04
+)
+
+var socksReplies = map[byte]string{
+	0x01: "general SOCKS server failure",
+	0x02: "connection not allowed by ruleset",
+	0x03: "network unreachable",
+	0x04: "host unreachable",
+	0x05: "connection refused",
+	0x06: "TTL expired",
+	0x07: "command not supported",
+	0x08: "address type not supported",
+}
+
+// socksConnect negotiates a CONNECT to host:port on conn, see RFC 1928 and RFC 1929.
+func socksConnect(conn net.Conn, user *url.Userinfo, host, port string, stats *ProxyStats) error {
+	portNum, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port %s", port)
+	}
+
+	start := time.Now()
+	method := byte(socksNoAuth)
+	if user != nil {
+		method = socksPassword
+	}
+	if _, err := conn.Write([]byte{socksVersion, 1, method}); err != nil {
+		return fmt.Errorf("socks handshake failed, %w", err)
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("socks handshake failed, %w", err)
+	}
+	if reply[0] != socksVersion {
+		return fmt.Errorf("socks handshake failed, unexpected version %d", reply[0])
+	}
+	if reply[1] == socksNoAcceptable {
+		return errors.New("socks handshake failed, no acceptable authentication method")
+	}
+	if method == socksPassword {
+		password, _ := user.Password()
+		if len(user.Username()) > 255 || len(password) > 255 {
+			return errors.New("socks username or password is too long")
+		}
+		request := []byte{0x01, byte(len(user.Username()))}
+		request = append(request, user.Username()...)
+		request = append(request, byte(len(password)))
+		request = append(request, password...)
+		if _, err := conn.Write(request); err != nil {
+			return fmt.Errorf("socks authentication failed, %w", err)
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return fmt.Errorf("socks authentication failed, %w", err)
+		}
+		if reply[1] != 0x00 {
+			return errors.New("socks authentication failed")
+		}
+	}
+	stats.Handshake = time.Since(start)
+
+	start = time.Now()
+	request := []byte{socksVersion, socksConnectCmd, 0x00}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return fmt.Errorf("host %s is too long", host)
+		}
+		request = append(request, socksDomain, byte(len(host)))
+		request = append(request, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		request = append(request, socksIPv4)
+		request = append(request, ip4...)
+	} else {
+		request = append(request, socksIPv6)
+		request = append(request, ip.To16()...)
+	}
+	request = append(request, byte(portNum>>8), byte(portNum))
+	if _, err := conn.Write(request); err != nil {
+		return fmt.Errorf("socks connect failed, %w", err)
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("socks connect failed, %w", err)
+	}
+	if header[1] != 0x00 {
+		reason, ok := socksReplies[header[1]]
+		if !ok {
+			reason = fmt.Sprintf("unknown reply %d", header[1])
+		}
+		return fmt.Errorf("socks connect failed, %s", reason)
+	}
+	// skip the bound address and port
+	var size int
+	switch header[3] {
+	case socksIPv4:
+		size = net.IPv4len
+	case socksIPv6:
+		size = net.IPv6len
+	case socksDomain:
+		if _, err := io.ReadFull(conn, header[:1]); err != nil {
+			return fmt.Errorf("socks connect failed, %w", err)
+		}
+		size = int(header[0])
+	default:
+		return fmt.Errorf("socks connect failed, unknown address type %d", header[3])
+	}
+	bound := make([]byte, size+2)
+	if _, err := io.ReadFull(conn, bound); err != nil {
+		return fmt.Errorf("socks connect failed, %w", err)
+	}
+	stats.Tunnel = time.Since(start)
+	return nil
+}
@@ -0,0 +1,177 @@
+package ping
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// serveSOCKS runs a SOCKS5 proxy on ln, it requires the password when user is not empty
+// and records the requested address to requests.
+func serveSOCKS(ln net.Listener, user, password string, requests chan<- string) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			header := make([]byte, 2)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			methods := make([]byte, header[1])
+			if _, err := io.ReadFull(conn, methods); err != nil {
+				return
+			}
+			method := byte(socksNoAuth)
+			if user != "" {
+				method = socksPassword
+			}
+			if methods[0] != method {
+				_, _ = conn.Write([]byte{socksVersion, socksNoAcceptable})
+				return
+			}
+			_, _ = conn.Write([]byte{socksVersion, method})
+			if user != "" {
+				buf := make([]byte, 512)
+				_, _ = io.ReadFull(conn, buf[:2])
+				u := make([]byte, buf[1])
+				_, _ = io.ReadFull(conn, u)
+				_, _ = io.ReadFull(conn, buf[:1])
+				p := make([]byte, buf[0])
+				_, _ = io.ReadFull(conn, p)
+				if string(u) != user || string(p) != password {
+					_, _ = conn.Write([]byte{0x01, 0x01})
+					return
+				}
+				_, _ = conn.Write([]byte{0x01, 0x00})
+			}
+
+			request := make([]byte, 4)
+			if _, err := io.ReadFull(conn, request); err != nil {
+				return
+			}
+			var host string
+			switch request[3] {
+			case socksIPv4:
+				ip := make([]byte, net.IPv4len)
+				_, _ = io.ReadFull(conn, ip)
+				host = net.IP(ip).String()
+			case socksIPv6:
+				ip := make([]byte, net.IPv6len)
+				_, _ = io.ReadFull(conn, ip)
+				host = net.IP(ip).String()
+			case socksDomain:
+				_, _ = io.ReadFull(conn, request[:1])
+				name := make([]byte, request[0])
+				_, _ = io.ReadFull(conn, name)
+				host = string(name)
+			}
+			port := make([]byte, 2)
+			_, _ = io.ReadFull(conn, port)
+			addr := net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1])))
+			requests <- addr
+
+			target, err := net.Dial("tcp", addr)
+			if err != nil {
+				_, _ = conn.Write([]byte{socksVersion, 0x05, 0x00, socksIPv4, 0, 0, 0, 0, 0, 0})
+				return
+			}
+			defer target.Close()
+			_, _ = conn.Write([]byte{socksVersion, 0x00, 0x00, socksIPv4, 127, 0, 0, 1, 0, 0})
+			go func() { _, _ = io.Copy(target, conn) }()
+			_, _ = io.Copy(conn, target)
+		}()
+	}
+}
+
+func TestDialProxy(t *testing.T) {
+
+	Convey("DialProxy", t, func() {
+		target, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer target.Close()
+		go func() {
+			for {
+				conn, err := target.Accept()
+				if err != nil {
+					return
+				}
+				_, _ = conn.Write([]byte("hello"))
+				conn.Close()
+			}
+		}()
+		_, port, _ := net.SplitHostPort(target.Addr().String())
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer ln.Close()
+		requests := make(chan string, 1)
+		go serveSOCKS(ln, "user", "secret", requests)
+
+		dial := func(proxy, addr string) (net.Conn, *ProxyStats, error) {
+			u, _ := url.Parse(proxy)
+			op := &Option{Proxy: u}
+			return DialProxy(context.Background(), op.Dialer("tcp"), op, addr)
+		}
+
+		Convey("without proxy", func() {
+			conn, proxyStats, err := DialProxy(context.Background(), &net.Dialer{}, &Option{}, target.Addr().String())
+			So(err, ShouldBeNil)
+			defer conn.Close()
+			So(proxyStats, ShouldBeNil)
+		})
+
+		Convey("socks5h", func() {
+			conn, proxyStats, err := dial("socks5h://user:secret@"+ln.Addr().String(), net.JoinHostPort("localhost", port))
+			So(err, ShouldBeNil)
+			defer conn.Close()
+			So(<-requests, ShouldEqual, net.JoinHostPort("localhost", port))
+			So(proxyStats.Connect, ShouldBeGreaterThan, 0)
+			So(proxyStats.Handshake, ShouldBeGreaterThan, 0)
+			So(proxyStats.Tunnel, ShouldBeGreaterThan, 0)
+			data, err := io.ReadAll(conn)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "hello")
+		})
+
+		Convey("socks5 resolves locally", func() {
+			conn, _, err := dial("socks5://user:secret@"+ln.Addr().String(), net.JoinHostPort("127.0.0.1", port))
+			So(err, ShouldBeNil)
+			defer conn.Close()
+			So(<-requests, ShouldEqual, net.JoinHostPort("127.0.0.1", port))
+		})
+
+		Convey("wrong password", func() {
+			_, _, err := dial("socks5://user:wrong@"+ln.Addr().String(), target.Addr().String())
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "socks authentication failed")
+		})
+
+		Convey("no authentication", func() {
+			_, _, err := dial("socks5://"+ln.Addr().String(), target.Addr().String())
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "no acceptable authentication method")
+		})
+
+		Convey("target refused", func() {
+			closed, _ := net.Listen("tcp", "127.0.0.1:0")
+			closed.Close()
+			_, _, err := dial("socks5h://user:secret@"+ln.Addr().String(), closed.Addr().String())
+			<-requests
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "socks connect failed, connection refused")
+		})
+
+		Convey("unsupported scheme", func() {
+			_, _, err := dial("ftp://"+ln.Addr().String(), target.Addr().String())
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
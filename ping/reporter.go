@@ -0,0 +1,154 @@
+package ping
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	_ Reporter = (*TextReporter)(nil)
+	_ Reporter = (*JSONReporter)(nil)
+)
+
+// NewReporter create the Reporter of format which writes to out.
+func NewReporter(format Format, out io.Writer, url *url.URL) Reporter {
+	switch format {
+	case FormatJSON, FormatNDJSON:
+		return NewJSONReporter(out, url, format == FormatNDJSON)
+	}
+	return NewTextReporter(out, url)
+}
+
+// NewTextReporter create a Reporter which writes human readable text to out.
+func NewTextReporter(out io.Writer, url *url.URL) *TextReporter {
+	return &TextReporter{
+		out: out,
+		url: url,
+	}
+}
+
+// TextReporter is the default Reporter.
+type TextReporter struct {
+	out io.Writer
+	url *url.URL
+}
+
+func (r *TextReporter) OnProbe(stats *Stats) {
+	status := "Failed"
+	if stats.Connected {
+		status = "connected"
+	}
+
+	// write the whole probe at once, the writer may be shared by other pingers
+	var builder strings.Builder
+	if stats.Error != nil {
+		_, _ = fmt.Fprintf(&builder, "Ping %s(%s) %s(%s) - time=%s dns=%s", r.url.String(), stats.Address, status, FormatError(stats.Error), stats.Duration, stats.DNSDuration)
+	} else if stats.Warning != "" {
+		_, _ = fmt.Fprintf(&builder, "Ping %s(%s) %s(warning: %s) - time=%s dns=%s", r.url.String(), stats.Address, status, stats.Warning, stats.Duration, stats.DNSDuration)
+	} else {
+		_, _ = fmt.Fprintf(&builder, "Ping %s(%s) %s - time=%s dns=%s", r.url.String(), stats.Address, status, stats.Duration, stats.DNSDuration)
+	}
+	if len(stats.Meta) > 0 {
+		_, _ = fmt.Fprintf(&builder, " %s", stats.FormatMeta())
+	}
+	builder.WriteString("\n")
+	if stats.Extra != nil {
+		_, _ = fmt.Fprintf(&builder, " %s\n", strings.TrimSpace(stats.Extra.String()))
+	}
+	_, _ = io.WriteString(r.out, builder.String())
+}
+
+func (r *TextReporter) OnSummary(result Result) {
+	_, _ = io.WriteString(r.out, result.String())
+}
+
+// NewJSONReporter create a Reporter which writes JSON to out,
+// every probe is written as one line when stream is true,
+// otherwise a single document is written on summary.
+func NewJSONReporter(out io.Writer, url *url.URL, stream bool) *JSONReporter {
+	return &JSONReporter{
+		out:    out,
+		url:    url,
+		stream: stream,
+	}
+}
+
+// JSONReporter writes JSON or NDJSON.
+type JSONReporter struct {
+	out    io.Writer
+	url    *url.URL
+	stream bool
+
+	seq    int
+	probes []probeJSON
+}
+
+func (r *JSONReporter) OnProbe(stats *Stats) {
+	probe := probeJSON{
+		Type:      "probe",
+		Target:    r.url.String(),
+		Seq:       r.seq,
+		Time:      time.Now(),
+		statsJSON: stats.toJSON(),
+	}
+	r.seq++
+	if r.stream {
+		r.write(probe)
+	} else {
+		r.probes = append(r.probes, probe)
+	}
+}
+
+func (r *JSONReporter) OnSummary(result Result) {
+	summary := result.toJSON()
+	summary.Target = r.url.String()
+	if r.stream {
+		r.write(summary)
+		return
+	}
+	probes := r.probes
+	if probes == nil {
+		probes = []probeJSON{}
+	}
+	r.write(documentJSON{
+		Target:  r.url.String(),
+		Probes:  probes,
+		Summary: summary,
+	})
+}
+
+func (r *JSONReporter) write(v interface{}) {
+	encoder := json.NewEncoder(r.out)
+	if !r.stream {
+		encoder.SetIndent("", "  ")
+	}
+	_ = encoder.Encode(v)
+}
+
+// WriteSummaryTable writes the results of several targets as a table.
+func WriteSummaryTable(out io.Writer, results []Result) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "\nTARGET\tSENT\tOK\tFAILED\tLOSS\tMIN\tAVG\tMAX\tP95")
+	for _, result := range results {
+		var loss float64
+		if result.Counter > 0 {
+			loss = float64(result.Failed()) / float64(result.Counter) * 100
+		}
+		target := ""
+		if result.Target != nil {
+			target = result.Target.String()
+			if result.Target.IP != "" {
+				target += " " + result.Target.IP
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.1f%%\t%s\t%s\t%s\t%s\n",
+			target, result.Counter, result.SuccessCounter, result.Failed(), loss,
+			result.MinDuration, result.Avg(), result.MaxDuration, result.P95)
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,144 @@
+package ping
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Statistics collects the durations of successful probes.
+//
+// Minimum, maximum, average, standard deviation and jitter are computed over
+// every sample, the percentiles are computed from a bounded reservoir of samples,
+// so the memory usage is constant for long runs.
+type Statistics struct {
+	count int
+	min   time.Duration
+	max   time.Duration
+	total time.Duration
+
+	// mean and m2 are used to compute the variance with Welford's algorithm
+	mean float64
+	m2   float64
+
+	last        time.Duration
+	jitterTotal time.Duration
+
+	size    int
+	samples []time.Duration
+	rand    *rand.Rand
+}
+
+// NewStatistics create a Statistics which keeps at most size samples for percentiles,
+// DefaultSamples is used if size is not positive.
+func NewStatistics(size int) *Statistics {
+	if size <= 0 {
+		size = DefaultSamples
+	}
+	return &Statistics{
+		size: size,
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Add records the duration of a successful probe.
+func (s *Statistics) Add(d time.Duration) {
+	s.count++
+	if s.count == 1 || d < s.min {
+		s.min = d
+	}
+	if d > s.max {
+		s.max = d
+	}
+	s.total += d
+
+	delta := float64(d) - s.mean
+	s.mean += delta / float64(s.count)
+	s.m2 += delta * (float64(d) - s.mean)
+
+	if s.count > 1 {
+		diff := d - s.last
+		if diff < 0 {
+			diff = -diff
+		}
+		s.jitterTotal += diff
+	}
+	s.last = d
+
+	// reservoir sampling, every sample has the same probability to be kept
+	if len(s.samples) < s.size {
+		s.samples = append(s.samples, d)
+	} else if i := s.rand.Intn(s.count); i < s.size {
+		s.samples[i] = d
+	}
+}
+
+// Count returns the number of samples.
+func (s *Statistics) Count() int {
+	return s.count
+}
+
+// Min returns the minimum duration.
+func (s *Statistics) Min() time.Duration {
+	return s.min
+}
+
+// Max returns the maximum duration.
+func (s *Statistics) Max() time.Duration {
+	return s.max
+}
+
+// Total returns the sum of all durations.
+func (s *Statistics) Total() time.Duration {
+	return s.total
+}
+
+// StdDev returns the population standard deviation.
+func (s *Statistics) StdDev() time.Duration {
+	if s.count == 0 {
+		return 0
+	}
+	return time.Duration(math.Sqrt(s.m2 / float64(s.count)))
+}
+
+// Jitter returns the mean absolute difference between consecutive samples, like RFC 3550.
+func (s *Statistics) Jitter() time.Duration {
+	if s.count < 2 {
+		return 0
+	}
+	return s.jitterTotal / time.Duration(s.count-1)
+}
+
+// Percentile returns the p-th (0 < p <= 100) percentile with the nearest-rank method.
+func (s *Statistics) Percentile(p float64) time.Duration {
+	return s.Percentiles(p)[0]
+}
+
+// Percentiles returns the percentiles of ps, the samples are sorted only once.
+func (s *Statistics) Percentiles(ps ...float64) []time.Duration {
+	result := make([]time.Duration, len(ps))
+	if len(s.samples) == 0 {
+		return result
+	}
+	sorted := make([]time.Duration, len(s.samples))
+	copy(sorted, s.samples)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	for i, p := range ps {
+		result[i] = percentile(sorted, p)
+	}
+	return result
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
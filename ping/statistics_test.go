@@ -0,0 +1,53 @@
+package ping
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStatistics(t *testing.T) {
+
+	Convey("Statistics", t, func() {
+		Convey("empty", func() {
+			s := NewStatistics(0)
+			So(s.Count(), ShouldEqual, 0)
+			So(s.Percentile(99), ShouldEqual, 0)
+			So(s.StdDev(), ShouldEqual, 0)
+			So(s.Jitter(), ShouldEqual, 0)
+		})
+
+		Convey("percentiles", func() {
+			s := NewStatistics(0)
+			for i := 100; i > 0; i-- {
+				s.Add(time.Duration(i) * time.Millisecond)
+			}
+			So(s.Min(), ShouldEqual, time.Millisecond)
+			So(s.Max(), ShouldEqual, 100*time.Millisecond)
+			So(s.Percentiles(50, 90, 95, 99), ShouldResemble, []time.Duration{
+				50 * time.Millisecond, 90 * time.Millisecond, 95 * time.Millisecond, 99 * time.Millisecond,
+			})
+		})
+
+		Convey("stddev and jitter", func() {
+			s := NewStatistics(0)
+			for _, d := range []time.Duration{2, 4, 4, 4, 5, 5, 7, 9} {
+				s.Add(d * time.Millisecond)
+			}
+			So(s.StdDev(), ShouldEqual, 2*time.Millisecond)
+			// |4-2|+0+0+|5-4|+0+|7-5|+|9-7| = 7ms over 7 intervals
+			So(s.Jitter(), ShouldEqual, time.Millisecond)
+		})
+
+		Convey("bounded samples", func() {
+			s := NewStatistics(10)
+			for i := 0; i < 1000; i++ {
+				s.Add(time.Duration(i))
+			}
+			So(s.Count(), ShouldEqual, 1000)
+			So(len(s.samples), ShouldEqual, 10)
+			So(s.Total(), ShouldEqual, time.Duration(999*1000/2))
+		})
+	})
+}
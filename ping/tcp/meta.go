@@ -1,6 +1,7 @@
 package tcp
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -27,6 +28,22 @@ func (m Meta) String() string {
 	)
 }
 
+func (m Meta) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ServerName string    `json:"serverName"`
+		Version    int       `json:"version"`
+		NotBefore  time.Time `json:"notBefore"`
+		NotAfter   time.Time `json:"notAfter"`
+		DNSNames   []string  `json:"dnsNames"`
+	}{
+		ServerName: m.serverName,
+		Version:    m.version,
+		NotBefore:  m.notBefore,
+		NotAfter:   m.notAfter,
+		DNSNames:   m.dnsNames,
+	})
+}
+
 func formatTime(t time.Time) string {
 	return t.Format(time.RFC3339)
 }
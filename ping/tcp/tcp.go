@@ -8,6 +8,7 @@ import (
 	"github.com/cloverstd/tcping/ping"
 	"net"
 	"net/http/httptrace"
+	"strconv"
 	"time"
 )
 
@@ -19,9 +20,7 @@ func New(host string, port int, op *ping.Option, tls bool) *Ping {
 		host:   host,
 		port:   port,
 		option: op,
-		dialer: &net.Dialer{
-			Resolver: op.Resolver,
-		},
+		dialer: op.Dialer("tcp"),
 	}
 }
 
@@ -53,27 +52,37 @@ func (p *Ping) Ping(ctx context.Context) *ping.Stats {
 		},
 	})
 
+	addr := net.JoinHostPort(p.host, strconv.Itoa(p.port))
 	start := time.Now()
 	var (
-		conn    net.Conn
-		err     error
 		tlsConn *tls.Conn
 		tlsErr  error
 	)
-	if p.tls {
-		tlsConn, err = tls.DialWithDialer(p.dialer, "tcp", fmt.Sprintf("%s:%d", p.host, p.port), &tls.Config{
-			InsecureSkipVerify: true,
-		})
-		if err == nil {
-			conn = tlsConn.NetConn()
-		} else {
-			tlsErr = err
-			conn, err = p.dialer.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", p.host, p.port))
+	conn, proxyStats, err := ping.DialProxy(ctx, p.dialer, p.option, addr)
+	connected := time.Since(start)
+	// the certificates are checked by --cert-warn-days without the meta
+	handshake := p.tls || p.option.CertWarnDays > 0
+	if err == nil {
+		defer conn.Close()
+		if handshake {
+			tlsConn = tls.Client(conn, &tls.Config{
+				ServerName:         p.host,
+				InsecureSkipVerify: true,
+			})
+			if tlsErr = tlsConn.HandshakeContext(ctx); tlsErr != nil {
+				tlsConn = nil
+			}
 		}
-	} else {
-		conn, err = p.dialer.DialContext(ctx, "tcp", fmt.Sprintf("%s:%d", p.host, p.port))
+	}
+	if proxyStats != nil {
+		stats.Meta = map[string]fmt.Stringer{}
+		proxyStats.SetMeta(stats.Meta)
 	}
 	stats.Duration = time.Since(start)
+	if !p.tls {
+		// the handshake only for the certificates is not a part of the ping
+		stats.Duration = connected
+	}
 	if err != nil {
 		stats.Error = err
 		if oe, ok := err.(*net.OpError); ok && oe.Addr != nil {
@@ -84,15 +93,20 @@ func (p *Ping) Ping(ctx context.Context) *ping.Stats {
 		stats.Address = conn.RemoteAddr().String()
 		if tlsConn != nil && len(tlsConn.ConnectionState().PeerCertificates) > 0 {
 			state := tlsConn.ConnectionState()
-			stats.Extra = Meta{
-				dnsNames:   state.PeerCertificates[0].DNSNames,
-				serverName: state.ServerName,
-				version:    int(state.Version - tls.VersionTLS10),
-				notBefore:  state.PeerCertificates[0].NotBefore,
-				notAfter:   state.PeerCertificates[0].NotAfter,
+			if p.tls {
+				stats.Extra = Meta{
+					dnsNames:   state.PeerCertificates[0].DNSNames,
+					serverName: state.ServerName,
+					version:    int(state.Version - tls.VersionTLS10),
+					notBefore:  state.PeerCertificates[0].NotBefore,
+					notAfter:   state.PeerCertificates[0].NotAfter,
+				}
 			}
+			ping.CheckCertificates(&stats, state.PeerCertificates, p.option.CertWarnDays)
 		} else if p.tls {
 			stats.Extra = bytes.NewBufferString(fmt.Sprintf("TLS handshake failed, %s", tlsErr))
+		} else if handshake {
+			stats.Warning = fmt.Sprintf("certificate not checked, TLS handshake failed, %s", tlsErr)
 		}
 	}
 	return &stats
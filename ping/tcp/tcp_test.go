@@ -2,9 +2,16 @@ package tcp_test
 
 import (
 	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
 	tcping "github.com/cloverstd/tcping/ping"
 	"github.com/cloverstd/tcping/ping/tcp"
-	"testing"
 )
 
 func TestPing(t *testing.T) {
@@ -23,3 +30,104 @@ func TestPing_Failed(t *testing.T) {
 		t.Fatalf("it should be connected refused error")
 	}
 }
+
+// connectProxy is a stand-in HTTP proxy which tunnels CONNECT requests with the proxy authorization auth.
+func connectProxy(t *testing.T, auth string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Proxy-Authorization") != auth {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer target.Close()
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		go func() { _, _ = io.Copy(target, conn) }()
+		_, _ = io.Copy(conn, target)
+	}))
+}
+
+func TestPing_HTTPProxy(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	addr := target.Addr().(*net.TCPAddr)
+
+	proxy := connectProxy(t, "Basic dXNlcjpzZWNyZXQ=")
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("user", "secret")
+	stats := tcp.New("127.0.0.1", addr.Port, &tcping.Option{Proxy: proxyURL}, false).Ping(context.Background())
+	if !stats.Connected {
+		t.Fatalf("ping failed, %s", stats.Error)
+	}
+	if status := stats.Meta["proxyStatus"].String(); status != "200" {
+		t.Fatalf("proxy status should be 200, got %s", status)
+	}
+	for _, key := range []string{"proxyConnect", "proxyTunnel"} {
+		if stats.Meta[key] == nil {
+			t.Fatalf("%s should be in meta", key)
+		}
+	}
+
+	proxyURL.User = nil
+	stats = tcp.New("127.0.0.1", addr.Port, &tcping.Option{Proxy: proxyURL}, false).Ping(context.Background())
+	if stats.Connected {
+		t.Fatalf("it should be proxy authentication required error")
+	}
+	if status := stats.Meta["proxyStatus"].String(); status != "407" {
+		t.Fatalf("proxy status should be 407, got %s", status)
+	}
+}
+
+func TestPing_CertWarnDays(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	addr := server.Listener.Addr().(*net.TCPAddr)
+
+	// the certificates are checked without the meta
+	ping := tcp.New("127.0.0.1", addr.Port, &tcping.Option{CertWarnDays: 1 << 20}, false)
+	stats := ping.Ping(context.Background())
+	if !stats.Connected {
+		t.Fatalf("ping failed, %s", stats.Error)
+	}
+	if stats.Warning == "" || stats.Meta["certDays"] == nil {
+		t.Fatalf("the certificate should expire within the warn days, got %q %v", stats.Warning, stats.Meta)
+	}
+	if stats.Extra != nil {
+		t.Fatalf("the TLS meta should not be reported without --meta, got %s", stats.Extra)
+	}
+
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer plain.Close()
+	addr = plain.Listener.Addr().(*net.TCPAddr)
+	ping = tcp.New("127.0.0.1", addr.Port, &tcping.Option{CertWarnDays: 30, Timeout: time.Second}, false)
+	if stats := ping.Ping(context.Background()); !stats.Connected || stats.Warning == "" {
+		t.Fatalf("the certificate of a plain TCP port should not be checked silently, got %v %q", stats.Error, stats.Warning)
+	}
+}
@@ -0,0 +1,61 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var _ fmt.Stringer = (*Certificate)(nil)
+
+// Certificate is the details of the leaf certificate of the peer.
+type Certificate struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	DNSNames  []string  `json:"dnsNames"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+}
+
+func newCertificate(cert *x509.Certificate) *Certificate {
+	return &Certificate{
+		Subject:   cert.Subject.CommonName,
+		Issuer:    cert.Issuer.CommonName,
+		DNSNames:  cert.DNSNames,
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+	}
+}
+
+func (c *Certificate) String() string {
+	return fmt.Sprintf(
+		"subject=%s issuer=%s notBefore=%s notAfter=%s dnsNames=%s",
+		c.Subject,
+		c.Issuer,
+		c.NotBefore.Format(time.RFC3339),
+		c.NotAfter.Format(time.RFC3339),
+		strings.Join(c.DNSNames, ","),
+	)
+}
+
+func (c *Certificate) MarshalJSON() ([]byte, error) {
+	type certificate Certificate
+	return json.Marshal((*certificate)(c))
+}
+
+var versions = map[uint16]string{
+	tls.VersionTLS10: "TLS1.0",
+	tls.VersionTLS11: "TLS1.1",
+	tls.VersionTLS12: "TLS1.2",
+	tls.VersionTLS13: "TLS1.3",
+}
+
+func versionName(version uint16) string {
+	if name, ok := versions[version]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
@@ -0,0 +1,139 @@
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cloverstd/tcping/ping"
+)
+
+var _ ping.Ping = (*Ping)(nil)
+
+// Config is the options of the TLS handshake.
+type Config struct {
+	// ServerName overrides the SNI, the host is used if it is empty
+	ServerName string
+	// ALPN is the protocols offered in the handshake
+	ALPN []string
+	// Verify verifies the certificate chain and the server name
+	Verify bool
+	// CAFile is the PEM bundle used to verify instead of the system roots
+	CAFile string
+}
+
+func New(host string, port int, op *ping.Option, config *Config) (*Ping, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	serverName := config.ServerName
+	if serverName == "" {
+		serverName = host
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		NextProtos:         config.ALPN,
+		InsecureSkipVerify: !config.Verify,
+	}
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read ca file failed, %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &Ping{
+		host:      host,
+		port:      port,
+		option:    op,
+		tlsConfig: tlsConfig,
+		dialer:    op.Dialer("tcp"),
+	}, nil
+}
+
+type Ping struct {
+	option    *ping.Option
+	host      string
+	port      int
+	tlsConfig *tls.Config
+	dialer    *net.Dialer
+}
+
+func (p *Ping) Ping(ctx context.Context) *ping.Stats {
+	timeout := ping.DefaultTimeout
+	if p.option.Timeout > 0 {
+		timeout = p.option.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stats := ping.Stats{
+		Meta: map[string]fmt.Stringer{},
+	}
+	var dnsStart time.Time
+	// trace dns query
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			dnsStart = time.Now()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			stats.DNSDuration = time.Since(dnsStart)
+		},
+	})
+
+	start := time.Now()
+	conn, proxyStats, err := ping.DialProxy(ctx, p.dialer, p.option, net.JoinHostPort(p.host, strconv.Itoa(p.port)))
+	connected := time.Now()
+	if proxyStats != nil {
+		proxyStats.SetMeta(stats.Meta)
+	}
+	if err != nil {
+		stats.Duration = connected.Sub(start)
+		stats.Error = err
+		if oe, ok := err.(*net.OpError); ok && oe.Addr != nil {
+			stats.Address = oe.Addr.String()
+		}
+		return &stats
+	}
+	defer conn.Close()
+	stats.Address = conn.RemoteAddr().String()
+	stats.Meta["connect"] = connected.Sub(start) - stats.DNSDuration
+	if proxyStats != nil {
+		// the target is connected by the proxy
+		stats.Meta["connect"] = proxyStats.Tunnel
+	}
+
+	tlsConn := tls.Client(conn, p.tlsConfig)
+	err = tlsConn.HandshakeContext(ctx)
+	stats.Duration = time.Since(start)
+	stats.Meta["handshake"] = time.Since(connected)
+	if err != nil {
+		stats.Error = fmt.Errorf("tls handshake failed, %w", err)
+		return &stats
+	}
+	stats.Connected = true
+
+	state := tlsConn.ConnectionState()
+	stats.Meta["version"] = ping.String(versionName(state.Version))
+	stats.Meta["cipher"] = ping.String(tls.CipherSuiteName(state.CipherSuite))
+	stats.Meta["serverName"] = ping.String(p.tlsConfig.ServerName)
+	if state.NegotiatedProtocol != "" {
+		stats.Meta["alpn"] = ping.String(state.NegotiatedProtocol)
+	}
+	if len(state.PeerCertificates) > 0 {
+		stats.Extra = newCertificate(state.PeerCertificates[0])
+	}
+	ping.CheckCertificates(&stats, state.PeerCertificates, p.option.CertWarnDays)
+	return &stats
+}
@@ -0,0 +1,100 @@
+package tls_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	tcping "github.com/cloverstd/tcping/ping"
+	"github.com/cloverstd/tcping/ping/tls"
+)
+
+func server(t *testing.T) (string, int, string) {
+	s := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(s.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p, caFile
+}
+
+func TestPing(t *testing.T) {
+	host, port, caFile := server(t)
+	ping, err := tls.New(host, port, &tcping.Option{}, &tls.Config{
+		ServerName: "example.com",
+		ALPN:       []string{"http/1.1"},
+		Verify:     true,
+		CAFile:     caFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := ping.Ping(context.Background())
+	if !stats.Connected {
+		t.Fatalf("ping failed, %s", stats.Error)
+	}
+	if stats.Meta["alpn"].String() != "http/1.1" {
+		t.Fatalf("alpn should be http/1.1, got %s", stats.Meta["alpn"])
+	}
+	if stats.Meta["serverName"].String() != "example.com" {
+		t.Fatalf("serverName should be example.com, got %s", stats.Meta["serverName"])
+	}
+	for _, key := range []string{"connect", "handshake", "version", "cipher"} {
+		if _, ok := stats.Meta[key]; !ok {
+			t.Fatalf("meta should contain %s", key)
+		}
+	}
+	if _, ok := stats.Extra.(*tls.Certificate); !ok {
+		t.Fatal("extra should be the certificate")
+	}
+}
+
+func TestPing_VerifyFailed(t *testing.T) {
+	host, port, _ := server(t)
+	ping, err := tls.New(host, port, &tcping.Option{}, &tls.Config{Verify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := ping.Ping(context.Background())
+	if stats.Connected {
+		t.Fatal("the certificate should not be trusted")
+	}
+
+	ping, err = tls.New(host, port, &tcping.Option{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats = ping.Ping(context.Background()); !stats.Connected {
+		t.Fatalf("it should not verify by default, %s", stats.Error)
+	}
+}
+
+func TestPing_CertWarnDays(t *testing.T) {
+	host, port, _ := server(t)
+	// the certificate of httptest expires in 2084
+	ping, err := tls.New(host, port, &tcping.Option{CertWarnDays: 365 * 100}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := ping.Ping(context.Background())
+	if !stats.Connected {
+		t.Fatalf("ping failed, %s", stats.Error)
+	}
+	if stats.Warning == "" {
+		t.Fatal("it should warn the certificate expiry")
+	}
+	if _, ok := stats.Meta["certDays"]; !ok {
+		t.Fatal("meta should contain certDays")
+	}
+}
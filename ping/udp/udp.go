@@ -0,0 +1,124 @@
+package udp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/cloverstd/tcping/ping"
+)
+
+var _ ping.Ping = (*Ping)(nil)
+
+// ErrPortUnreachable is reported when the ICMP port unreachable is received,
+// it is surfaced as ECONNREFUSED on the connected UDP socket.
+var ErrPortUnreachable = errors.New("port unreachable")
+
+// DefaultPayload is sent when the payload is empty.
+var DefaultPayload = []byte("tcping")
+
+const maxDatagramSize = 64 * 1024
+
+func New(host string, port int, op *ping.Option, payload []byte) *Ping {
+	if len(payload) == 0 {
+		payload = DefaultPayload
+	}
+	return &Ping{
+		host:    host,
+		port:    port,
+		option:  op,
+		payload: payload,
+		dialer:  op.Dialer("udp"),
+	}
+}
+
+type Ping struct {
+	option  *ping.Option
+	host    string
+	port    int
+	payload []byte
+	dialer  *net.Dialer
+}
+
+func (p *Ping) Ping(ctx context.Context) *ping.Stats {
+	timeout := ping.DefaultTimeout
+	if p.option.Timeout > 0 {
+		timeout = p.option.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stats := ping.Stats{
+		Meta: map[string]fmt.Stringer{},
+	}
+	var dnsStart time.Time
+	// trace dns query
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			dnsStart = time.Now()
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			stats.DNSDuration = time.Since(dnsStart)
+		},
+	})
+
+	start := time.Now()
+	conn, err := p.dialer.DialContext(ctx, p.option.Network("udp"), p.option.DialAddress(net.JoinHostPort(p.host, strconv.Itoa(p.port))))
+	if err != nil {
+		stats.Duration = time.Since(start)
+		stats.Error = err
+		return &stats
+	}
+	defer conn.Close()
+	stats.Address = conn.RemoteAddr().String()
+
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	// interrupt the read when ctx is canceled
+	go func() {
+		<-ctx.Done()
+		_ = conn.SetDeadline(time.Now())
+	}()
+
+	if _, err = conn.Write(p.payload); err == nil {
+		buf := make([]byte, maxDatagramSize)
+		var n int
+		n, err = conn.Read(buf)
+		if err == nil {
+			stats.Meta["bytes"] = ping.Int(n)
+		}
+	}
+	stats.Duration = time.Since(start)
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			err = &unreachableError{err: err}
+		} else if ctx.Err() != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = ctx.Err()
+		}
+		stats.Error = err
+		return &stats
+	}
+	stats.Connected = true
+	return &stats
+}
+
+type unreachableError struct {
+	err error
+}
+
+func (e *unreachableError) Error() string {
+	return ErrPortUnreachable.Error()
+}
+
+func (e *unreachableError) Unwrap() error {
+	return e.err
+}
+
+func (e *unreachableError) Is(target error) bool {
+	return target == ErrPortUnreachable
+}
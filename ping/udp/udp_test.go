@@ -0,0 +1,76 @@
+package udp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	tcping "github.com/cloverstd/tcping/ping"
+	"github.com/cloverstd/tcping/ping/udp"
+)
+
+func listen(t *testing.T, echo bool) *net.UDPAddr {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if echo {
+				_, _ = conn.WriteTo(buf[:n], addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr)
+}
+
+func TestPing(t *testing.T) {
+	addr := listen(t, true)
+	ping := udp.New("127.0.0.1", addr.Port, &tcping.Option{}, []byte("hello"))
+	stats := ping.Ping(context.Background())
+	if !stats.Connected {
+		t.Fatalf("ping failed, %s", stats.Error)
+	}
+	if stats.Meta["bytes"] != tcping.Int(5) {
+		t.Fatalf("it should receive 5 bytes, got %s", stats.Meta["bytes"])
+	}
+}
+
+func TestPing_Timeout(t *testing.T) {
+	addr := listen(t, false)
+	ping := udp.New("127.0.0.1", addr.Port, &tcping.Option{Timeout: 100 * time.Millisecond}, nil)
+	stats := ping.Ping(context.Background())
+	if stats.Connected {
+		t.Fatal("it should be timeout")
+	}
+	if tcping.FormatError(stats.Error) != "timeout" {
+		t.Fatalf("it should be timeout, got %s", stats.Error)
+	}
+}
+
+func TestPing_Unreachable(t *testing.T) {
+	// nothing is listening on the port after the conn is closed
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+
+	ping := udp.New("127.0.0.1", port, &tcping.Option{Timeout: time.Second}, nil)
+	stats := ping.Ping(context.Background())
+	if stats.Connected {
+		t.Fatal("it should be port unreachable")
+	}
+	if !errors.Is(stats.Error, udp.ErrPortUnreachable) {
+		t.Fatalf("it should be port unreachable, got %s", stats.Error)
+	}
+}
@@ -38,11 +38,14 @@ func ParseDuration(t string) (time.Duration, error) {
 	return time.ParseDuration(t)
 }
 
-// ParseAddress will try to parse addr as url.URL.
+// ParseAddress will try to parse addr as url.URL, a bare IPv6 like ::1 is the host.
 func ParseAddress(addr string) (*url.URL, error) {
 	if strings.Contains(addr, "://") {
 		// it maybe with scheme, try url.Parse
 		return url.Parse(addr)
 	}
+	if ip := net.ParseIP(addr); ip != nil && ip.To4() == nil {
+		addr = "[" + addr + "]"
+	}
 	return url.Parse("tcp://" + addr)
 }
@@ -40,3 +40,33 @@ func TestFormatIP(t *testing.T) {
 		})
 	})
 }
+
+func TestParseAddress(t *testing.T) {
+
+	Convey("ParseAddress", t, func() {
+		Convey("for host and port", func() {
+			u, err := ParseAddress("google.com:443")
+			So(err, ShouldBeNil)
+			So(u.Scheme, ShouldEqual, "tcp")
+			So(u.Host, ShouldEqual, "google.com:443")
+		})
+
+		Convey("for bare v6", func() {
+			u, err := ParseAddress("2001:db8::1")
+			So(err, ShouldBeNil)
+			So(u.Hostname(), ShouldEqual, "2001:db8::1")
+			So(u.Port(), ShouldEqual, "")
+		})
+
+		Convey("for v6 with port", func() {
+			u, err := ParseAddress("[::1]:22")
+			So(err, ShouldBeNil)
+			So(u.Hostname(), ShouldEqual, "::1")
+			So(u.Port(), ShouldEqual, "22")
+		})
+
+		Convey("for target", func() {
+			So(Target{Protocol: TCP, Host: "::1", Port: 443}.String(), ShouldEqual, "tcp://[::1]:443")
+		})
+	})
+}
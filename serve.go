@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/cloverstd/tcping/ping"
+	"github.com/cloverstd/tcping/ping/exporter"
+	"github.com/spf13/cobra"
+)
+
+var listen string
+
+var serveCmd = &cobra.Command{
+	Use:   "serve [host...]",
+	Short: "serve the probe results as Prometheus metrics",
+	Long: `serve pings the targets continuously and exposes the results as Prometheus metrics on /metrics,
+/probe?target=host pings the target on demand like the blackbox exporter.`,
+	Example: `
+  1. serve the metrics of several targets
+  	> tcping serve --listen :9100 google.com:443 https://hui.lu
+  2. serve the metrics of the targets in a file
+  	> tcping serve -f targets.txt
+  3. serve on demand probes only
+  	> tcping serve --listen :9100
+  	> curl 'localhost:9100/probe?target=https://hui.lu&count=3&timeout=2s'
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		targets, err := collectTargets(args)
+		if err != nil {
+			cmd.Println("load targets failed", err)
+			exitCode = exitUsage
+			return
+		}
+
+		if err := checkAddressFlags(); err != nil {
+			cmd.Println(err)
+			cmd.Usage()
+			exitCode = exitUsage
+			return
+		}
+
+		timeoutDuration, err := ping.ParseDuration(timeout)
+		if err != nil {
+			cmd.Println("parse timeout failed", err)
+			cmd.Usage()
+			exitCode = exitUsage
+			return
+		}
+
+		intervalDuration, err := ping.ParseDuration(interval)
+		if err != nil {
+			cmd.Println("parse interval failed", err)
+			cmd.Usage()
+			exitCode = exitUsage
+			return
+		}
+
+		metrics := exporter.New()
+		pingers := make([]*ping.Pinger, 0, len(targets))
+		for _, t := range targets {
+			url, err := t.URL()
+			if err != nil {
+				cmd.Println(err)
+				exitCode = exitUsage
+				return
+			}
+			option := newOption(timeoutDuration)
+			p, err := newPing(url, &option)
+			if err != nil {
+				cmd.Println(err)
+				cmd.Usage()
+				exitCode = exitUsage
+				return
+			}
+			// counter 0 pings until the server is stopped
+			pingers = append(pingers, ping.NewPinger(metrics.Reporter(url), url, p, intervalDuration, 0))
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics)
+		mux.Handle("/probe", &probeHandler{
+			timeout:  timeoutDuration,
+			interval: intervalDuration,
+		})
+		server := &http.Server{
+			Addr:    listen,
+			Handler: mux,
+		}
+
+		group := ping.NewGroup(pingers...)
+		go group.Ping()
+
+		errC := make(chan error, 1)
+		go func() {
+			errC <- server.ListenAndServe()
+		}()
+		cmd.Printf("serving metrics on %s/metrics\n", listen)
+
+		sigs = make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		select {
+		case <-sigs:
+		case err := <-errC:
+			cmd.Println("serve failed", err)
+			exitCode = exitFailure
+		}
+		group.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	},
+}
+
+const (
+	// defaultProbeCount is the number of pings of a /probe request
+	defaultProbeCount = 1
+	// maxProbeCount limits the duration of a /probe request
+	maxProbeCount = 100
+)
+
+// probeHandler pings the target of the request, the query parameters are
+//
+//	target   - the target, like the command line argument
+//	port     - optional port of the target
+//	count    - number of pings, default 1
+//	timeout  - the timeout of each ping, default --timeout
+//	interval - the interval between pings, default --interval
+//	format   - "prometheus" (default) or "json"
+type probeHandler struct {
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func (h *probeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("target") == "" {
+		http.Error(w, "target is required", http.StatusBadRequest)
+		return
+	}
+	url, err := target{host: query.Get("target"), port: query.Get("port")}.URL()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	count := defaultProbeCount
+	if v := query.Get("count"); v != "" {
+		if count, err = strconv.Atoi(v); err != nil || count < 1 || count > maxProbeCount {
+			http.Error(w, fmt.Sprintf("count must be between 1 and %d", maxProbeCount), http.StatusBadRequest)
+			return
+		}
+	}
+	timeout := h.timeout
+	if v := query.Get("timeout"); v != "" {
+		if timeout, err = ping.ParseDuration(v); err != nil {
+			http.Error(w, "parse timeout failed, "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	interval := h.interval
+	if v := query.Get("interval"); v != "" {
+		if interval, err = ping.ParseDuration(v); err != nil {
+			http.Error(w, "parse interval failed, "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	option := newOption(timeout)
+	p, err := newPing(url, &option)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var (
+		buf      bytes.Buffer
+		reporter ping.Reporter
+		metrics  *exporter.Exporter
+	)
+	switch format := query.Get("format"); format {
+	case "", "prometheus":
+		metrics = exporter.New()
+		reporter = metrics.Reporter(url)
+	case "json":
+		reporter = ping.NewJSONReporter(&buf, url, false)
+	default:
+		http.Error(w, fmt.Sprintf("format %s not support", format), http.StatusBadRequest)
+		return
+	}
+
+	pinger := ping.NewPinger(reporter, url, p, interval, count)
+	go func() {
+		// stop pinging when the client goes away
+		select {
+		case <-r.Context().Done():
+			pinger.Stop()
+		case <-pinger.Done():
+		}
+	}()
+	pinger.Ping()
+	pinger.Summarize()
+
+	if metrics != nil {
+		metrics.ServeHTTP(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(buf.Bytes())
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&listen, "listen", ":9100", `the address to serve the metrics`)
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func probe(query url.Values) *httptest.ResponseRecorder {
+	handler := &probeHandler{timeout: time.Second, interval: time.Millisecond}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/probe?"+query.Encode(), nil))
+	return w
+}
+
+func TestProbeHandlerBadRequest(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		query url.Values
+	}{
+		{"missing target", url.Values{}},
+		{"invalid target", url.Values{"target": {"tcp://[::1"}}},
+		{"invalid port", url.Values{"target": {"127.0.0.1"}, "port": {"http"}}},
+		{"unknown protocol", url.Values{"target": {"gopher://127.0.0.1:70"}}},
+		{"invalid count", url.Values{"target": {"127.0.0.1:80"}, "count": {"x"}}},
+		{"zero count", url.Values{"target": {"127.0.0.1:80"}, "count": {"0"}}},
+		{"count over max", url.Values{"target": {"127.0.0.1:80"}, "count": {"101"}}},
+		{"invalid timeout", url.Values{"target": {"127.0.0.1:80"}, "timeout": {"soon"}}},
+		{"invalid interval", url.Values{"target": {"127.0.0.1:80"}, "interval": {"soon"}}},
+		{"unknown format", url.Values{"target": {"127.0.0.1:80"}, "format": {"xml"}}},
+	} {
+		if w := probe(tt.query); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: the status should be 400, got %d %s", tt.name, w.Code, w.Body)
+		}
+	}
+}
+
+func TestProbeHandler(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	target := "tcp://" + ln.Addr().String()
+
+	w := probe(url.Values{"target": {target}, "count": {"3"}, "timeout": {"2s"}, "format": {"json"}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("the status should be 200, got %d %s", w.Code, w.Body)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("the content type should be json, got %s", contentType)
+	}
+	var document struct {
+		Probes  []json.RawMessage `json:"probes"`
+		Summary struct {
+			Total      int `json:"total"`
+			Successful int `json:"successful"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatalf("invalid json %s, %s", w.Body, err)
+	}
+	if len(document.Probes) != 3 || document.Summary.Total != 3 || document.Summary.Successful != 3 {
+		t.Fatalf("the count should be 3, got %s", w.Body)
+	}
+
+	w = probe(url.Values{"target": {target}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("the status should be 200, got %d %s", w.Code, w.Body)
+	}
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+		t.Fatalf("the content type should be the Prometheus text format, got %s", contentType)
+	}
+	for _, line := range []string{
+		`tcping_probe_up{target="` + target + `",protocol="tcp"} 1`,
+		`tcping_probe_success_total{target="` + target + `",protocol="tcp"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), line+"\n") {
+			t.Fatalf("the metrics should contain %s, got\n%s", line, w.Body)
+		}
+	}
+}
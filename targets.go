@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cloverstd/tcping/ping"
+	"github.com/cloverstd/tcping/ping/dns"
+)
+
+// target is a host with an optional port from the command line or the targets file.
+type target struct {
+	host string
+	port string
+}
+
+// parseArgs keeps the "host port" form for a single target,
+// otherwise every argument is a target.
+func parseArgs(args []string) []target {
+	if len(args) == 2 {
+		if _, err := strconv.Atoi(args[1]); err == nil {
+			return []target{{host: args[0], port: args[1]}}
+		}
+	}
+	targets := make([]target, 0, len(args))
+	for _, arg := range args {
+		targets = append(targets, target{host: arg})
+	}
+	return targets
+}
+
+// collectTargets returns the targets of args and the targets file.
+func collectTargets(args []string) ([]target, error) {
+	targets := parseArgs(args)
+	if targetsFile != "" {
+		fileTargets, err := loadTargets(targetsFile)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, fileTargets...)
+	}
+	return targets, nil
+}
+
+// loadTargets reads the targets from file, "-" is stdin.
+// Every line is "host [port]" or an URI, blank lines and lines starting with # are ignored.
+func loadTargets(file string) ([]target, error) {
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var targets []target
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 1:
+			targets = append(targets, target{host: fields[0]})
+		case 2:
+			targets = append(targets, target{host: fields[0], port: fields[1]})
+		default:
+			return nil, fmt.Errorf("%s:%d: invalid target %q", file, n, line)
+		}
+	}
+	return targets, scanner.Err()
+}
+
+// URL returns the target as url.URL with the port filled.
+func (t target) URL() (*url.URL, error) {
+	url, err := ping.ParseAddress(t.host)
+	if err != nil {
+		return nil, fmt.Errorf("%s is an invalid target", t.host)
+	}
+
+	defaultPort := "80"
+	if port := url.Port(); port != "" {
+		defaultPort = port
+	} else if url.Scheme == "https" || url.Scheme == "tls" {
+		defaultPort = "443"
+	} else if url.Scheme == "dns" {
+		defaultPort = dns.DefaultPort
+	}
+	if t.port != "" {
+		defaultPort = t.port
+	}
+	if url.Scheme == "udp" && url.Port() == "" && t.port == "" {
+		// there is no well-known port of a UDP service
+		return nil, fmt.Errorf("%s requires a port, e.g. udp://%s:53", t.host, url.Hostname())
+	}
+	port, err := strconv.Atoi(defaultPort)
+	if err != nil {
+		return nil, fmt.Errorf("%s is invalid port", defaultPort)
+	}
+	url.Host = net.JoinHostPort(url.Hostname(), strconv.Itoa(port))
+	return url, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	for _, tt := range []struct {
+		args    []string
+		targets []target
+	}{
+		{[]string{"google.com"}, []target{{host: "google.com"}}},
+		{[]string{"google.com", "443"}, []target{{host: "google.com", port: "443"}}},
+		{[]string{"a.com", "b.com"}, []target{{host: "a.com"}, {host: "b.com"}}},
+		{[]string{"a.com", "b.com", "443"}, []target{{host: "a.com"}, {host: "b.com"}, {host: "443"}}},
+		{nil, []target{}},
+	} {
+		if targets := parseArgs(tt.args); !reflect.DeepEqual(targets, tt.targets) {
+			t.Errorf("%v: the targets should be %v, got %v", tt.args, tt.targets, targets)
+		}
+	}
+}
+
+func TestLoadTargets(t *testing.T) {
+	write := func(content string) string {
+		file := filepath.Join(t.TempDir(), "targets.txt")
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+
+	targets, err := loadTargets(write(`
+# the comments and blank lines are ignored
+google.com 443
+
+  https://hui.lu
+	# indented comment
+dns://8.8.8.8
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []target{{host: "google.com", port: "443"}, {host: "https://hui.lu"}, {host: "dns://8.8.8.8"}}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Fatalf("the targets should be %v, got %v", expected, targets)
+	}
+
+	if _, err := loadTargets(write("google.com 443\ngoogle.com 443 extra\n")); err == nil {
+		t.Fatal("the line with 3 fields should be invalid")
+	}
+	if _, err := loadTargets(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Fatal("the missing file should fail")
+	}
+}
+
+func TestTargetURL(t *testing.T) {
+	for _, tt := range []struct {
+		target target
+		url    string
+	}{
+		{target{host: "google.com"}, "tcp://google.com:80"},
+		{target{host: "google.com", port: "443"}, "tcp://google.com:443"},
+		{target{host: "google.com:22"}, "tcp://google.com:22"},
+		{target{host: "http://hui.lu/path"}, "http://hui.lu:80/path"},
+		{target{host: "https://hui.lu"}, "https://hui.lu:443"},
+		{target{host: "https://hui.lu", port: "8443"}, "https://hui.lu:8443"},
+		{target{host: "tls://hui.lu"}, "tls://hui.lu:443"},
+		{target{host: "dns://8.8.8.8"}, "dns://8.8.8.8:53"},
+		{target{host: "dns://8.8.8.8:5353"}, "dns://8.8.8.8:5353"},
+		{target{host: "udp://8.8.8.8:53"}, "udp://8.8.8.8:53"},
+		{target{host: "::1", port: "443"}, "tcp://[::1]:443"},
+		{target{host: "[::1]:22"}, "tcp://[::1]:22"},
+		{target{host: "https://[2001:db8::1]"}, "https://[2001:db8::1]:443"},
+		{target{host: "udp://8.8.8.8", port: "123"}, "udp://8.8.8.8:123"},
+	} {
+		u, err := tt.target.URL()
+		if err != nil {
+			t.Errorf("%v: %s", tt.target, err)
+			continue
+		}
+		if u.String() != tt.url {
+			t.Errorf("%v: the url should be %s, got %s", tt.target, tt.url, u)
+		}
+	}
+
+	for _, tt := range []target{
+		{host: "google.com", port: "https"},
+		{host: "tcp://[::1"},
+		{host: "udp://8.8.8.8"},
+	} {
+		if _, err := tt.URL(); err == nil {
+			t.Errorf("%v should be invalid", tt)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cloverstd/tcping/ping"
+	"golang.org/x/term"
+)
+
+const (
+	// sparklineWidth is the number of recent probes in the sparkline
+	sparklineWidth = 30
+	// refreshInterval is the redraw interval of the dashboard
+	refreshInterval = 200 * time.Millisecond
+)
+
+// sparklineLevels are the ASCII levels from the fastest to the slowest probe.
+var sparklineLevels = []byte("_.-~=+*#")
+
+// isTerminal reports whether f is a terminal, other character devices like /dev/null are not.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// newTerminalDashboard returns the dashboard when it is enabled by tui for the text format on a terminal,
+// it returns nil to fall back to the scrolling lines.
+func newTerminalDashboard(tui bool, format ping.Format, f *os.File) *dashboard {
+	if !tui || format != ping.FormatText || !isTerminal(f) {
+		return nil
+	}
+	return newDashboard(f)
+}
+
+// dashboard redraws a table of targets instead of scrolling lines.
+type dashboard struct {
+	mu   sync.Mutex
+	out  io.Writer
+	rows []*dashboardRow
+}
+
+func newDashboard(out io.Writer) *dashboard {
+	return &dashboard{out: out}
+}
+
+// Reporter returns the Reporter of a target, the row is shown in the order of creation.
+// The address labels the row of every address of a target with --all-addresses, it can be empty.
+func (d *dashboard) Reporter(url *url.URL, address string) ping.Reporter {
+	row := &dashboardRow{
+		mu:         &d.mu,
+		url:        url,
+		address:    address,
+		statistics: ping.NewStatistics(ping.DefaultSamples),
+	}
+	d.mu.Lock()
+	d.rows = append(d.rows, row)
+	d.mu.Unlock()
+	return row
+}
+
+// Run redraws the dashboard until ctx is done, the last frame is kept on the screen.
+func (d *dashboard) Run(ctx context.Context) {
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		d.draw()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			d.draw()
+			return
+		}
+	}
+}
+
+func (d *dashboard) draw() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var builder strings.Builder
+	// move the cursor to the top left and clear the screen
+	builder.WriteString("\033[H\033[2J")
+	w := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "TARGET\tLAST\tSENT\tLOSS\tMIN\tAVG\tMAX\tRECENT")
+	for _, row := range d.rows {
+		_, _ = fmt.Fprintln(w, row.String())
+	}
+	_ = w.Flush()
+	_, _ = io.WriteString(d.out, builder.String())
+}
+
+type dashboardRow struct {
+	mu *sync.Mutex
+
+	url        *url.URL
+	address    string
+	last       *ping.Stats
+	sent       int
+	failed     int
+	statistics *ping.Statistics
+	// recent durations, a negative duration is a failed probe
+	recent []time.Duration
+}
+
+func (r *dashboardRow) OnProbe(stats *ping.Stats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.last = stats
+	r.sent++
+	d := stats.Duration
+	if stats.Error != nil {
+		r.failed++
+		d = -1
+	} else {
+		r.statistics.Add(stats.Duration)
+	}
+	r.recent = append(r.recent, d)
+	if len(r.recent) > sparklineWidth {
+		r.recent = r.recent[len(r.recent)-sparklineWidth:]
+	}
+}
+
+func (r *dashboardRow) OnSummary(result ping.Result) {}
+
+func (r *dashboardRow) String() string {
+	last := "-"
+	if r.last != nil {
+		if r.last.Error != nil {
+			last = ping.FormatError(r.last.Error)
+		} else {
+			last = r.last.Duration.String()
+		}
+	}
+	var loss float64
+	var avg time.Duration
+	if r.sent > 0 {
+		loss = float64(r.failed) / float64(r.sent) * 100
+	}
+	if n := r.statistics.Count(); n > 0 {
+		avg = r.statistics.Total() / time.Duration(n)
+	}
+	target := r.url.String()
+	if r.address != "" {
+		target = fmt.Sprintf("%s (%s)", target, r.address)
+	}
+	return fmt.Sprintf("%s\t%s\t%d\t%.1f%%\t%s\t%s\t%s\t%s",
+		target, last, r.sent, loss,
+		r.statistics.Min(), avg, r.statistics.Max(), sparkline(r.recent))
+}
+
+// sparkline renders the durations scaled between their minimum and maximum,
+// the failed probes are shown as "!".
+func sparkline(durations []time.Duration) string {
+	var min, max time.Duration = -1, -1
+	for _, d := range durations {
+		if d < 0 {
+			continue
+		}
+		if min < 0 || d < min {
+			min = d
+		}
+		if d > max {
+			max = d
+		}
+	}
+	line := make([]byte, 0, len(durations))
+	for _, d := range durations {
+		switch {
+		case d < 0:
+			line = append(line, '!')
+		case max == min:
+			line = append(line, sparklineLevels[0])
+		default:
+			level := int(int64(d-min) * int64(len(sparklineLevels)-1) / int64(max-min))
+			line = append(line, sparklineLevels[level])
+		}
+	}
+	return string(line)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloverstd/tcping/ping"
+)
+
+func TestSparkline(t *testing.T) {
+	ms := time.Millisecond
+	for _, tt := range []struct {
+		name      string
+		durations []time.Duration
+		line      string
+	}{
+		{"empty", nil, ""},
+		{"same durations", []time.Duration{ms, ms, ms}, "___"},
+		{"scaled", []time.Duration{0, 7 * ms, 1 * ms, 4 * ms}, "_#.="},
+		{"failed probes", []time.Duration{ms, -1, 8 * ms}, "_!#"},
+		{"only failed probes", []time.Duration{-1, -1}, "!!"},
+	} {
+		if line := sparkline(tt.durations); line != tt.line {
+			t.Errorf("%s: the sparkline should be %q, got %q", tt.name, tt.line, line)
+		}
+	}
+}
+
+func TestDashboardFallback(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if isTerminal(f) {
+		t.Fatal("a regular file should not be a terminal")
+	}
+	if board := newTerminalDashboard(true, ping.FormatText, f); board != nil {
+		t.Fatal("the dashboard should fall back to the lines when the output is not a terminal")
+	}
+	if board := newTerminalDashboard(false, ping.FormatText, f); board != nil {
+		t.Fatal("the dashboard should be disabled without --tui")
+	}
+
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+	if board := newTerminalDashboard(true, ping.FormatText, null); board != nil {
+		t.Fatalf("the dashboard should fall back to the lines for %s", os.DevNull)
+	}
+}
+
+func TestDashboardRows(t *testing.T) {
+	u, _ := url.Parse("tcp://example.com:443")
+	var out bytes.Buffer
+	board := newDashboard(&out)
+	board.Reporter(u, "").OnProbe(&ping.Stats{Connected: true, Duration: time.Millisecond})
+	board.Reporter(u, "93.184.216.34")
+	board.Reporter(u, "2606:2800:220:1:248:1893:25c8:1946")
+	board.draw()
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("the dashboard should have a header and 3 rows, got %q", out.String())
+	}
+	for i, target := range []string{
+		"tcp://example.com:443 ",
+		"tcp://example.com:443 (93.184.216.34)",
+		"tcp://example.com:443 (2606:2800:220:1:248:1893:25c8:1946)",
+	} {
+		if !strings.HasPrefix(lines[i+1], target) {
+			t.Errorf("the row %d should start with %q, got %q", i+1, target, lines[i+1])
+		}
+	}
+}